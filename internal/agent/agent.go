@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -21,11 +22,20 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 	scnorion_nats "github.com/scncore/nats"
 	"github.com/scncore/scnorion-agent/internal/agent/rustdesk"
+	"github.com/scncore/scnorion-agent/internal/commands/compliance"
 	"github.com/scncore/scnorion-agent/internal/commands/deploy"
+	"github.com/scncore/scnorion-agent/internal/commands/discovery"
+	"github.com/scncore/scnorion-agent/internal/commands/firewall"
+	"github.com/scncore/scnorion-agent/internal/commands/patch"
+	"github.com/scncore/scnorion-agent/internal/commands/power"
 	"github.com/scncore/scnorion-agent/internal/commands/printers"
+	"github.com/scncore/scnorion-agent/internal/commands/processes"
 	remotedesktop "github.com/scncore/scnorion-agent/internal/commands/remote-desktop"
 	"github.com/scncore/scnorion-agent/internal/commands/report"
+	"github.com/scncore/scnorion-agent/internal/commands/services"
 	"github.com/scncore/scnorion-agent/internal/commands/sftp"
+	"github.com/scncore/scnorion-agent/internal/commands/telemetry"
+	"github.com/scncore/scnorion-agent/internal/commands/wol"
 	ansiblecfg "github.com/scncore/scnorion-ansible-config/ansible"
 	scnorion_utils "github.com/scncore/utils"
 	"github.com/scncore/wingetcfg/wingetcfg"
@@ -47,12 +57,26 @@ type Agent struct {
 	SFTPServer             *sftp.SFTP
 	JetstreamContextCancel context.CancelFunc
 	WingetConfigureJob     gocron.Job
+	PatchJob               gocron.Job
+	DiskHealthStatus       map[string]string
+	Telemetry              *telemetry.Sampler
 }
 
 type JSONActions struct {
 	Actions []scnorion_nats.DeployAction `json:"actions"`
 }
 
+// DeployResult adds the package manager error to the deploy action
+type DeployResult struct {
+	scnorion_nats.DeployAction
+	Error *deploy.DeployError `json:"error,omitempty"`
+}
+
+// PatchConfig is read from the remote config message, if the console doesn't send it the patch schedule is disabled
+type PatchConfig struct {
+	PatchSchedule *patch.Schedule `json:"patch_schedule,omitempty"`
+}
+
 type ProfileConfig struct {
 	ProfileID     int                          `yaml:"profileID"`
 	Exclusions    []string                     `yaml:"exclusions"`
@@ -129,7 +153,7 @@ func (a *Agent) RunReport() *report.Report {
 	log.Println(">>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>")
 
 	log.Println("[INFO]: agent is running a report...")
-	r, err := report.RunReport(a.Config.UUID, a.Config.Enabled, a.Config.Debug, a.Config.VNCProxyPort, a.Config.SFTPPort, a.Config.IPAddress, a.Config.SFTPDisabled, a.Config.RemoteAssistanceDisabled, a.Config.TenantID, a.Config.SiteID)
+	r, err := report.RunReport(a.Config.UUID, a.Config.Enabled, a.Config.Debug, a.Config.VNCProxyPort, a.Config.SFTPPort, a.Config.IPAddress, a.Config.SFTPDisabled, a.Config.RemoteAssistanceDisabled, a.Config.TenantID, a.Config.SiteID, a.Config.ReportConnections)
 	if err != nil {
 		return nil
 	}
@@ -157,6 +181,9 @@ func (a *Agent) RunReport() *report.Report {
 		return nil
 	}
 
+	// Attach the performance aggregates since the last report was sent
+	r.Telemetry = a.Telemetry.Aggregate()
+
 	log.Printf("[INFO]: agent report run took %v\n", time.Since(start))
 
 	log.Println("<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<")
@@ -177,6 +204,41 @@ func (a *Agent) SendReport(r *report.Report) error {
 	if err != nil {
 		return err
 	}
+
+	if r.Telemetry != nil {
+		a.Telemetry.Reset(r.Telemetry.PeriodEnd)
+	}
+
+	// The login events are sent only once
+	if r.LoginHistory != nil {
+		if err := report.SaveLoginCursor(r.LoginHistory.Cursor); err != nil {
+			log.Printf("[ERROR]: could not save the login history cursor, reason: %v", err)
+		}
+	}
+	return nil
+}
+
+func (a *Agent) startTelemetryJob() error {
+	if a.Config.TelemetryInterval <= 0 {
+		log.Println("[INFO]: telemetry is disabled")
+		return nil
+	}
+
+	a.Telemetry = telemetry.New(time.Duration(a.Config.TelemetryInterval) * time.Second)
+
+	_, err := a.TaskScheduler.NewJob(
+		gocron.DurationJob(
+			a.Telemetry.Interval,
+		),
+		gocron.NewTask(a.Telemetry.Sample),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		log.Printf("[ERROR]: could not start the telemetry job: %v", err)
+		return err
+	}
+	log.Printf("[INFO]: new telemetry job has been scheduled every %d seconds", a.Config.TelemetryInterval)
 	return nil
 }
 
@@ -255,7 +317,7 @@ func (a *Agent) PendingACKTask() {
 
 	j := 0
 	for i := 0; i < len(actions); i++ {
-		if err := a.SendDeployResult(&actions[i]); err != nil {
+		if err := a.SendDeployResult(&actions[i], nil); err != nil {
 			log.Printf("[ERROR]: sending deployment result from task failed!, reason: %s\n", err.Error())
 			j = j + 1
 		} else {
@@ -416,10 +478,10 @@ func (a *Agent) InstallPackageSubscribe() error {
 			return
 		}
 
-		if err := deploy.InstallPackage(action.PackageId); err != nil {
-			log.Printf("[ERROR]: could not deploy package using package manager, reason: %v\n", err)
+		if deployErr := deploy.InstallPackage(action.PackageId); deployErr != nil {
+			log.Printf("[ERROR]: could not deploy package using package manager, reason: %v\n", deployErr)
 			action.Failed = true
-			if err := a.SendDeployResult(&action); err != nil {
+			if err := a.SendDeployResult(&action, deployErr); err != nil {
 				log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 				if err := SaveDeploymentNotACK(action); err != nil {
 					log.Println("[ERROR]: could not save deployment pending ack to JSON file", err)
@@ -431,7 +493,7 @@ func (a *Agent) InstallPackageSubscribe() error {
 		// Send deploy result if succesful
 		action.When = time.Now()
 		action.Failed = false
-		if err := a.SendDeployResult(&action); err != nil {
+		if err := a.SendDeployResult(&action, nil); err != nil {
 			log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 			if err := SaveDeploymentNotACK(action); err != nil {
 				log.Println("[ERROR]: could not save deployment pending ack to JSON file", err)
@@ -464,13 +526,13 @@ func (a *Agent) UpdatePackageSubscribe() error {
 			return
 		}
 
-		if err := deploy.UpdatePackage(action.PackageId); err != nil {
-			if strings.Contains(err.Error(), strings.ToLower("0x8A15002B")) {
-				log.Println("[INFO]: could not update package using package manager, no updates found", err)
+		if deployErr := deploy.UpdatePackage(action.PackageId); deployErr != nil {
+			if strings.Contains(deployErr.Error(), strings.ToLower("0x8A15002B")) {
+				log.Println("[INFO]: could not update package using package manager, no updates found", deployErr)
 			} else {
-				log.Printf("[ERROR]: could not update package using package manager, reason: %v\n", err)
+				log.Printf("[ERROR]: could not update package using package manager, reason: %v\n", deployErr)
 				action.Failed = true
-				if err := a.SendDeployResult(&action); err != nil {
+				if err := a.SendDeployResult(&action, deployErr); err != nil {
 					log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 				}
 			}
@@ -480,7 +542,7 @@ func (a *Agent) UpdatePackageSubscribe() error {
 		// Send deploy result if succesful
 		action.When = time.Now()
 		action.Failed = false
-		if err := a.SendDeployResult(&action); err != nil {
+		if err := a.SendDeployResult(&action, nil); err != nil {
 			log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 			if err := SaveDeploymentNotACK(action); err != nil {
 				log.Println("[ERROR]: could not save deployment pending ack to JSON file", err)
@@ -514,10 +576,10 @@ func (a *Agent) UninstallPackageSubscribe() error {
 			return
 		}
 
-		if err := deploy.UninstallPackage(action.PackageId); err != nil {
-			log.Printf("[ERROR]: could not uninstall package, reason: %v\n", err)
+		if deployErr := deploy.UninstallPackage(action.PackageId); deployErr != nil {
+			log.Printf("[ERROR]: could not uninstall package, reason: %v\n", deployErr)
 			action.Failed = false
-			if err := a.SendDeployResult(&action); err != nil {
+			if err := a.SendDeployResult(&action, deployErr); err != nil {
 				log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 			}
 			return
@@ -525,7 +587,7 @@ func (a *Agent) UninstallPackageSubscribe() error {
 
 		// Send deploy result if succesful
 		action.When = time.Now()
-		if err := a.SendDeployResult(&action); err != nil {
+		if err := a.SendDeployResult(&action, nil); err != nil {
 			log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
 			if err := SaveDeploymentNotACK(action); err != nil {
 				log.Println("[ERROR]: could not save deployment pending ack to JSON file", err)
@@ -549,6 +611,72 @@ func (a *Agent) UninstallPackageSubscribe() error {
 	return nil
 }
 
+func (a *Agent) InstallArtifactSubscribe() error {
+	_, err := a.NATSConnection.Subscribe("agent.installartifact."+a.Config.UUID, func(msg *nats.Msg) {
+
+		artifact := deploy.ArtifactDeployment{}
+		err := json.Unmarshal(msg.Data, &artifact)
+		if err != nil {
+			log.Printf("[ERROR]: could not get the artifact to install, reason: %v\n", err)
+			return
+		}
+
+		// Downloads can take a while so we don't block the NATS handler
+		go func() {
+			deployErr := deploy.InstallArtifact(&artifact, a.OpenArtifactObject, a.Config.ArtifactSigningKey)
+
+			action := scnorion_nats.DeployAction{
+				AgentId:     a.Config.UUID,
+				PackageId:   artifact.PackageID,
+				PackageName: artifact.PackageName,
+				Action:      "install",
+				When:        time.Now(),
+				Failed:      deployErr != nil,
+			}
+			if err := a.SendDeployResult(&action, deployErr); err != nil {
+				log.Printf("[ERROR]: could not send deploy result to worker, reason: %v\n", err)
+				if deployErr == nil {
+					if err := SaveDeploymentNotACK(action); err != nil {
+						log.Println("[ERROR]: could not save deployment pending ack to JSON file", err)
+					}
+				}
+			}
+			if deployErr != nil {
+				return
+			}
+
+			// Send a report to update the installed apps
+			r := a.RunReport()
+			if r == nil {
+				return
+			}
+			if err := a.SendReport(r); err != nil {
+				log.Printf("[ERROR]: report could not be send to NATS server!, reason: %s\n", err.Error())
+			}
+		}()
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent install artifact, reason: %v", err)
+	}
+	return nil
+}
+
+// OpenArtifactObject reads an artifact uploaded by the console to a JetStream object store
+func (a *Agent) OpenArtifactObject(ctx context.Context, bucket, object string) (io.ReadCloser, error) {
+	js, err := jetstream.New(a.NATSConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := js.ObjectStore(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Get(ctx, object)
+}
+
 func (a *Agent) AgentSettingsSubscribe() error {
 	_, err := a.NATSConnection.Subscribe("agent.settings."+a.Config.UUID, func(msg *nats.Msg) {
 
@@ -608,8 +736,15 @@ func (a *Agent) AgentSettingsSubscribe() error {
 	return nil
 }
 
-func (a *Agent) SendDeployResult(r *scnorion_nats.DeployAction) error {
-	data, err := json.Marshal(r)
+func (a *Agent) SendDeployResult(r *scnorion_nats.DeployAction, deployErr error) error {
+	result := DeployResult{DeployAction: *r}
+	if deployErr != nil {
+		if !errors.As(deployErr, &result.Error) {
+			result.Error = &deploy.DeployError{PackageID: r.PackageId, ExitCode: -1, Message: deployErr.Error()}
+		}
+	}
+
+	data, err := json.Marshal(result)
 	if err != nil {
 		return err
 	}
@@ -758,6 +893,11 @@ func (a *Agent) SubscribeToNATSSubjects() {
 		log.Printf("[ERROR]: %v\n", err)
 	}
 
+	err = a.InstallArtifactSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
 	err = a.UpdatePackageSubscribe()
 	if err != nil {
 		log.Printf("[ERROR]: %v\n", err)
@@ -783,6 +923,71 @@ func (a *Agent) SubscribeToNATSSubjects() {
 		log.Printf("[ERROR]: %v\n", err)
 	}
 
+	err = a.CancelShutdownSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.LogoffSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.LockScreenSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.SuspendSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.HibernateSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.WakeOnLANSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.NetworkDiscoverySubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.PatchSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.ProcessesSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.ProcessControlSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.ServiceSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.FirewallSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
+	err = a.ComplianceSubscribe()
+	if err != nil {
+		log.Printf("[ERROR]: %v\n", err)
+	}
+
 	err = a.AgentSettingsSubscribe()
 	if err != nil {
 		log.Printf("[ERROR]: %v\n", err)
@@ -892,6 +1097,12 @@ func (a *Agent) GetRemoteConfig() error {
 		if a.Config.Debug {
 			log.Printf("[DEBUG]: new default frequency is %d", a.Config.DefaultFrequency)
 		}
+
+		patchConfig := PatchConfig{}
+		if err := json.Unmarshal(msg.Data, &patchConfig); err != nil {
+			log.Printf("[ERROR]: could not read the patch schedule from remote config, reason: %v", err)
+		}
+		a.SchedulePatchTask(patchConfig.PatchSchedule)
 	}
 	return nil
 }
@@ -970,6 +1181,147 @@ func (a *Agent) RemovePrinter() error {
 	return nil
 }
 
+func (a *Agent) CancelShutdownSubscribe() error {
+	return a.powerActionSubscribe("agent.cancelshutdown."+a.Config.UUID, "cancel shutdown", power.CancelShutdown, false)
+}
+
+func (a *Agent) LogoffSubscribe() error {
+	return a.powerActionSubscribe("agent.logoff."+a.Config.UUID, "logoff", power.Logoff, false)
+}
+
+func (a *Agent) LockScreenSubscribe() error {
+	return a.powerActionSubscribe("agent.lock."+a.Config.UUID, "lock screen", power.Lock, false)
+}
+
+func (a *Agent) SuspendSubscribe() error {
+	return a.powerActionSubscribe("agent.suspend."+a.Config.UUID, "suspend", power.Suspend, true)
+}
+
+func (a *Agent) HibernateSubscribe() error {
+	return a.powerActionSubscribe("agent.hibernate."+a.Config.UUID, "hibernate", power.Hibernate, true)
+}
+
+// powerActionSubscribe answers with the error of the action, if any. Suspend and hibernate
+// won't return until the computer wakes up so they must answer first
+func (a *Agent) powerActionSubscribe(subject, description string, action func() error, respondFirst bool) error {
+	_, err := a.NATSConnection.QueueSubscribe(subject, "scnorion-agent-management", func(msg *nats.Msg) {
+		log.Printf("[INFO]: %s request received", description)
+
+		if respondFirst {
+			if err := msg.Respond(nil); err != nil {
+				log.Printf("[ERROR]: could not respond to agent %s message, reason: %v\n", description, err)
+			}
+		}
+
+		var response []byte
+		if err := action(); err != nil {
+			log.Printf("[ERROR]: could not %s, reason: %v\n", description, err)
+			response = []byte(err.Error())
+		}
+
+		if !respondFirst {
+			if err := msg.Respond(response); err != nil {
+				log.Printf("[ERROR]: could not respond to agent %s message, reason: %v\n", description, err)
+			}
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent %s, reason: %v", description, err)
+	}
+	return nil
+}
+
+func (a *Agent) WakeOnLANSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.wol."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		log.Println("[INFO]: wake on lan request received")
+
+		result := &wol.WakeOnLANResult{}
+		req := wol.WakeOnLANRequest{}
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			log.Printf("[ERROR]: could not unmarshal wake on lan request, reason: %v\n", err)
+			result.Error = err.Error()
+		} else {
+			result, err = wol.WakeUp(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not send wake on lan packets, reason: %v\n", err)
+				result = &wol.WakeOnLANResult{Error: err.Error()}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal wake on lan result, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent wake on lan message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent wake on lan, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) NetworkDiscoverySubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.discovery."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		log.Println("[INFO]: network discovery request received")
+
+		req := discovery.DiscoveryRequest{}
+		if len(msg.Data) > 0 {
+			if err := json.Unmarshal(msg.Data, &req); err != nil {
+				log.Printf("[ERROR]: could not unmarshal network discovery request, reason: %v\n", err)
+				if err := msg.Respond([]byte(err.Error())); err != nil {
+					log.Printf("[ERROR]: could not respond to agent network discovery message, reason: %v\n", err)
+				}
+				return
+			}
+		}
+
+		// A discovery takes a while so we answer now and send the results when it finishes
+		if err := msg.Respond(nil); err != nil {
+			log.Printf("[ERROR]: could not respond to agent network discovery message, reason: %v\n", err)
+		}
+
+		go func() {
+			result, err := discovery.Discover(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not run network discovery, reason: %v\n", err)
+				result = &discovery.DiscoveryResult{Error: err.Error()}
+			}
+			result.AgentID = a.Config.UUID
+
+			if err := a.SendNetworkDiscoveryResult(result); err != nil {
+				log.Printf("[ERROR]: could not send network discovery result to worker, reason: %v\n", err)
+			}
+		}()
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent network discovery, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) SendNetworkDiscoveryResult(r *discovery.DiscoveryResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if a.NATSConnection == nil {
+		return fmt.Errorf("NATS connection is not ready")
+	}
+
+	if _, err := a.NATSConnection.Request("networkdiscovery.report", data, 2*time.Minute); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (a *Agent) SendWinGetCfgProfileApplicationReport(profileID int, agentID string, success bool, errData string) error {
 	// Notify worker if application was succesful or not
 	deployment := scnorion_nats.WingetCfgReport{
@@ -1060,3 +1412,304 @@ func (a *Agent) StopRustDeskSubscribe() error {
 	}
 	return nil
 }
+
+func (a *Agent) PatchSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.patch."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		log.Println("[INFO]: patch installation request received")
+
+		req := patch.PatchRequest{}
+		if len(msg.Data) > 0 {
+			if err := json.Unmarshal(msg.Data, &req); err != nil {
+				log.Printf("[ERROR]: could not unmarshal patch request, reason: %v\n", err)
+				if err := msg.Respond([]byte(err.Error())); err != nil {
+					log.Printf("[ERROR]: could not respond to agent patch message, reason: %v\n", err)
+				}
+				return
+			}
+		}
+
+		// Updates take a while so we answer now and send the results when they're installed
+		if err := msg.Respond(nil); err != nil {
+			log.Printf("[ERROR]: could not respond to agent patch message, reason: %v\n", err)
+		}
+
+		go a.RunPatch(req, false)
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent patch, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) RunPatch(req patch.PatchRequest, scheduled bool) {
+	result, err := patch.Run(req)
+	if err != nil {
+		log.Printf("[ERROR]: could not install patches, reason: %v\n", err)
+		if result == nil {
+			result = &patch.PatchResult{SecurityOnly: req.SecurityOnly, StartedAt: time.Now()}
+		}
+		result.Error = err.Error()
+		result.FinishedAt = time.Now()
+	}
+	result.AgentID = a.Config.UUID
+	result.Scheduled = scheduled
+
+	if err := a.SendPatchResult(result); err != nil {
+		log.Printf("[ERROR]: could not send patch result to worker, reason: %v\n", err)
+	}
+
+	// Send a report to update the pending updates
+	r := a.RunReport()
+	if r == nil {
+		return
+	}
+	if err := a.SendReport(r); err != nil {
+		log.Printf("[ERROR]: report could not be send to NATS server!, reason: %s\n", err.Error())
+	}
+}
+
+func (a *Agent) SendPatchResult(r *patch.PatchResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.NATSConnection.Request("patch.report", data, 2*time.Minute); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SchedulePatchTask replaces the patch job with the schedule received from the console
+func (a *Agent) SchedulePatchTask(schedule *patch.Schedule) {
+	if a.PatchJob != nil {
+		if err := a.TaskScheduler.RemoveJob(a.PatchJob.ID()); err != nil {
+			log.Printf("[ERROR]: could not remove the patch job, reason: %v", err)
+		}
+		a.PatchJob = nil
+	}
+
+	if schedule == nil || !schedule.Enabled || !patch.Supported() {
+		return
+	}
+
+	hour, minute, err := patch.ParseClock(schedule.Time)
+	if err != nil {
+		log.Printf("[ERROR]: could not schedule the patch job, reason: %v", err)
+		return
+	}
+	atTimes := gocron.NewAtTimes(gocron.NewAtTime(uint(hour), uint(minute), 0))
+
+	var definition gocron.JobDefinition
+	switch schedule.Frequency {
+	case patch.FREQUENCY_DAILY:
+		definition = gocron.DailyJob(1, atTimes)
+	case patch.FREQUENCY_WEEKLY:
+		definition = gocron.WeeklyJob(1, gocron.NewWeekdays(time.Weekday(schedule.Weekday%7)), atTimes)
+	default:
+		log.Printf("[ERROR]: could not schedule the patch job, frequency %s is not valid", schedule.Frequency)
+		return
+	}
+
+	a.PatchJob, err = a.TaskScheduler.NewJob(
+		definition,
+		gocron.NewTask(func() {
+			a.RunPatch(schedule.Request(time.Now()), true)
+		}),
+	)
+	if err != nil {
+		log.Printf("[ERROR]: could not schedule the patch job, reason: %v", err)
+		return
+	}
+	log.Printf("[INFO]: patch job has been scheduled %s at %s", schedule.Frequency, schedule.Time)
+}
+
+func (a *Agent) ProcessesSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.processes."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		log.Println("[INFO]: process list request received")
+
+		result := &processes.ProcessListResult{}
+		req := processes.ProcessListRequest{}
+		if len(msg.Data) > 0 {
+			if err := json.Unmarshal(msg.Data, &req); err != nil {
+				log.Printf("[ERROR]: could not unmarshal process list request, reason: %v\n", err)
+				result.Error = err.Error()
+			}
+		}
+
+		if result.Error == "" {
+			var err error
+			result, err = processes.List(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not get the process list, reason: %v\n", err)
+				result = &processes.ProcessListResult{Error: err.Error()}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal process list, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent processes message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent processes, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) ProcessControlSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.processcontrol."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		result := &processes.ProcessControlResult{}
+		req := processes.ProcessControlRequest{}
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			log.Printf("[ERROR]: could not unmarshal process control request, reason: %v\n", err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("[INFO]: process control request received, action: %s, pid: %d, name: %s", req.Action, req.PID, req.Name)
+
+			result, err = processes.Control(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not run process control action, reason: %v\n", err)
+				result = &processes.ProcessControlResult{Error: err.Error()}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal process control result, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent process control message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent process control, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) ServiceSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.service."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		result := &services.ServiceResult{}
+		req := services.ServiceRequest{}
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			log.Printf("[ERROR]: could not unmarshal service request, reason: %v\n", err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("[INFO]: service request received, action: %s, unit: %s", req.Action, req.Unit)
+
+			result.Unit, err = services.Control(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not run service action, reason: %v\n", err)
+				result.Error = err.Error()
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal service result, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent service message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent service, reason: %v", err)
+	}
+	return nil
+}
+
+func (a *Agent) FirewallSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.firewall."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		result := &firewall.FirewallResult{}
+		req := firewall.FirewallRequest{}
+		if err := json.Unmarshal(msg.Data, &req); err != nil {
+			log.Printf("[ERROR]: could not unmarshal firewall request, reason: %v\n", err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("[INFO]: firewall request received, action: %s, port: %d, source: %s", req.Action, req.Port, req.Source)
+
+			// The SFTP server and the VNC proxy must still be reachable once the firewall is enabled
+			if port, err := strconv.Atoi(a.Config.SFTPPort); err == nil && !a.Config.SFTPDisabled {
+				req.SFTPPort = port
+			}
+			if port, err := strconv.Atoi(a.Config.VNCProxyPort); err == nil && !a.Config.RemoteAssistanceDisabled {
+				req.VNCProxyPort = port
+			}
+
+			result.Firewall, err = firewall.Control(req)
+			if err != nil {
+				log.Printf("[ERROR]: could not run firewall action, reason: %v\n", err)
+				result.Error = err.Error()
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal firewall result, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent firewall message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent firewall, reason: %v", err)
+	}
+	return nil
+}
+
+// ComplianceSubscribe receives the compliance policy, it's evaluated now and again with every report
+func (a *Agent) ComplianceSubscribe() error {
+	_, err := a.NATSConnection.QueueSubscribe("agent.compliance."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
+		result := &compliance.ComplianceResult{AgentID: a.Config.UUID}
+
+		p, err := compliance.ParsePolicy(msg.Data)
+		if err != nil {
+			log.Printf("[ERROR]: could not parse compliance policy, reason: %v\n", err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("[INFO]: compliance policy %s received with %d rules", p.Name, len(p.Rules))
+
+			if err := compliance.SavePolicy(p); err != nil {
+				log.Printf("[ERROR]: could not save compliance policy, reason: %v\n", err)
+			}
+
+			if len(p.Rules) > 0 {
+				result = compliance.Evaluate(p)
+				result.AgentID = a.Config.UUID
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			log.Printf("[ERROR]: could not marshal compliance result, reason: %v\n", err)
+			return
+		}
+
+		if err := msg.Respond(data); err != nil {
+			log.Printf("[ERROR]: could not respond to agent compliance message, reason: %v\n", err)
+		}
+	})
+
+	if err != nil {
+		return fmt.Errorf("[ERROR]: could not subscribe to agent compliance, reason: %v", err)
+	}
+	return nil
+}
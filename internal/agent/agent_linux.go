@@ -15,7 +15,6 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/apenella/go-ansible/v2/pkg/execute"
@@ -30,7 +29,9 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	scnorion_nats "github.com/scncore/nats"
+	"github.com/scncore/scnorion-agent/internal/commands/power"
 	rd "github.com/scncore/scnorion-agent/internal/commands/remote-desktop"
+	"github.com/scncore/scnorion-agent/internal/commands/report"
 	"github.com/scncore/scnorion-agent/internal/commands/sftp"
 	ansiblecfg "github.com/scncore/scnorion-ansible-config/ansible"
 	scnorion_utils "github.com/scncore/utils"
@@ -62,6 +63,9 @@ func (a *Agent) Start() {
 	a.TaskScheduler.Start()
 	log.Println("[INFO]: task scheduler has started!")
 
+	// Start sampling performance telemetry, it doesn't need the NATS connection
+	a.startTelemetryJob()
+
 	// Start BadgerDB KV and SFTP server only if port is set
 	if a.Config.SFTPPort != "" && !a.Config.SFTPDisabled {
 		cwd, err := Getwd()
@@ -139,6 +143,7 @@ func (a *Agent) Start() {
 	// Start other jobs associated
 	a.startPendingACKJob()
 	a.startCheckForAnsibleProfilesJob()
+	a.startDiskHealthJob()
 }
 
 func (a *Agent) startNATSConnectJob() error {
@@ -168,6 +173,7 @@ func (a *Agent) startNATSConnectJob() error {
 				a.startReportJob()
 				a.startPendingACKJob()
 				a.startCheckForAnsibleProfilesJob()
+				a.startDiskHealthJob()
 			},
 		),
 	)
@@ -224,21 +230,14 @@ func (a *Agent) RebootSubscribe() error {
 			log.Printf("[ERROR]: could not respond to agent reboot message, reason: %v\n", err)
 		}
 
-		action := scnorion_nats.RebootOrRestart{}
+		action := power.PowerAction{}
 		if err := json.Unmarshal(msg.Data, &action); err != nil {
 			log.Printf("[ERROR]: could not unmarshal to agent reboot message, reason: %v\n", err)
 			return
 		}
 
-		when := int(time.Until(action.Date).Minutes())
-		if when > 0 {
-			if err := exec.Command("shutdown", "-r", strconv.Itoa(when)).Run(); err != nil {
-				log.Printf("[ERROR]: could not initiate power off, reason: %v", err)
-			}
-		} else {
-			if err := exec.Command("shutdown", "-r", "now").Run(); err != nil {
-				log.Printf("[ERROR]: could not initiate shutdown, reason: %v", err)
-			}
+		if err := power.Reboot(action); err != nil {
+			log.Printf("[ERROR]: could not initiate reboot, reason: %v", err)
 		}
 	})
 
@@ -256,21 +255,14 @@ func (a *Agent) PowerOffSubscribe() error {
 			return
 		}
 
-		action := scnorion_nats.RebootOrRestart{}
+		action := power.PowerAction{}
 		if err := json.Unmarshal(msg.Data, &action); err != nil {
 			log.Printf("[ERROR]: could not unmarshal to agent power off message, reason: %v\n", err)
 			return
 		}
 
-		when := int(time.Until(action.Date).Minutes())
-		if when > 0 {
-			if err := exec.Command("shutdown", "-P", strconv.Itoa(when)).Run(); err != nil {
-				log.Printf("[ERROR]: could not initiate power off, reason: %v", err)
-			}
-		} else {
-			if err := exec.Command("shutdown", "-P", "now").Run(); err != nil {
-				log.Printf("[ERROR]: could not initiate shutdown, reason: %v", err)
-			}
+		if err := power.PowerOff(action); err != nil {
+			log.Printf("[ERROR]: could not initiate power off, reason: %v", err)
 		}
 	})
 
@@ -657,3 +649,80 @@ func installCommunityGeneralCollection() error {
 
 	return workflow.NewWorkflowExecute(galaxyInstallCollectionExec).WithTrace().Execute(context.TODO())
 }
+
+// startDiskHealthJob checks the SMART health more often than the report so a
+// disk that starts failing is notified as soon as possible
+func (a *Agent) startDiskHealthJob() error {
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		log.Println("[INFO]: smartctl is not installed, disk health job is not scheduled")
+		return nil
+	}
+
+	_, err := a.TaskScheduler.NewJob(
+		gocron.DurationJob(
+			SCHEDULETIME_30MIN*time.Minute,
+		),
+		gocron.NewTask(a.CheckDiskHealth),
+		gocron.WithStartAt(gocron.WithStartImmediately()),
+	)
+	if err != nil {
+		log.Printf("[ERROR]: could not start the disk health job: %v", err)
+		return err
+	}
+	log.Printf("[INFO]: new disk health job has been scheduled every %d minutes", SCHEDULETIME_30MIN)
+	return nil
+}
+
+// CheckDiskHealth sends an event for every disk whose status has changed, the
+// first check after the agent starts only saves the current status
+func (a *Agent) CheckDiskHealth() {
+	disks, err := report.GetDisksHealth()
+	if err != nil {
+		log.Printf("[ERROR]: could not check disks health, reason: %v", err)
+		return
+	}
+
+	firstCheck := a.DiskHealthStatus == nil
+	if firstCheck {
+		a.DiskHealthStatus = map[string]string{}
+	}
+
+	for _, d := range disks {
+		previous, found := a.DiskHealthStatus[d.Key()]
+		a.DiskHealthStatus[d.Key()] = d.Status
+		if firstCheck || !found || previous == d.Status {
+			continue
+		}
+
+		log.Printf("[INFO]: disk %s health has changed from %s to %s", d.Device, previous, d.Status)
+
+		event := report.DiskHealthEvent{
+			AgentID:        a.Config.UUID,
+			PreviousStatus: previous,
+			Disk:           d,
+			Time:           time.Now(),
+		}
+		if err := a.SendDiskHealthEvent(&event); err != nil {
+			log.Printf("[ERROR]: could not send disk health event, reason: %v", err)
+			// Try again on next check
+			a.DiskHealthStatus[d.Key()] = previous
+		}
+	}
+}
+
+func (a *Agent) SendDiskHealthEvent(event *report.DiskHealthEvent) error {
+	if a.NATSConnection == nil {
+		return errors.New("NATS connection is not ready")
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.NATSConnection.Request("diskhealth.event", data, 2*time.Minute); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/nats-io/nats.go/jetstream"
 	scnorion_nats "github.com/scncore/nats"
 	"github.com/scncore/scnorion-agent/internal/commands/deploy"
+	"github.com/scncore/scnorion-agent/internal/commands/power"
 	rd "github.com/scncore/scnorion-agent/internal/commands/remote-desktop"
 	"github.com/scncore/scnorion-agent/internal/commands/report"
 	"github.com/scncore/scnorion-agent/internal/commands/sftp"
@@ -58,6 +59,9 @@ func (a *Agent) Start() {
 	a.TaskScheduler.Start()
 	log.Println("[INFO]: task scheduler has started!")
 
+	// Start sampling performance telemetry, it doesn't need the NATS connection
+	a.startTelemetryJob()
+
 	// Start BadgerDB KV and SFTP server only if port is set
 	if a.Config.SFTPPort != "" && !a.Config.SFTPDisabled {
 		cwd, err := Getwd()
@@ -228,16 +232,26 @@ func (a *Agent) StartRemoteDesktopSubscribe() error {
 func (a *Agent) RebootSubscribe() error {
 	_, err := a.NATSConnection.QueueSubscribe("agent.reboot."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
 		log.Println("[INFO]: reboot request received")
-		if err := msg.Respond([]byte("Reboot!")); err != nil {
-			log.Printf("[ERROR]: could not respond to agent reboot message, reason: %v\n", err)
-		}
 
-		action := scnorion_nats.RebootOrRestart{}
+		action := power.PowerAction{}
 		if err := json.Unmarshal(msg.Data, &action); err != nil {
 			log.Printf("[ERROR]: could not unmarshal to agent reboot message, reason: %v\n", err)
+			if err := msg.Respond([]byte(err.Error())); err != nil {
+				log.Printf("[ERROR]: could not respond to agent reboot message, reason: %v\n", err)
+			}
 			return
 		}
 
+		// The console is told the user won't be warned
+		response := "Reboot!"
+		if action.NotifyUser && !power.NOTIFY_USER_SUPPORTED {
+			response += " User notification is not supported on this operating system"
+			log.Println("[INFO]: the user can't be notified before the reboot on this operating system")
+		}
+		if err := msg.Respond([]byte(response)); err != nil {
+			log.Printf("[ERROR]: could not respond to agent reboot message, reason: %v\n", err)
+		}
+
 		when := int(time.Until(action.Date).Seconds())
 		if when > 0 {
 			if err := exec.Command("cmd", "/C", "shutdown", "/r", "/t", strconv.Itoa(when)).Run(); err != nil {
@@ -259,17 +273,26 @@ func (a *Agent) RebootSubscribe() error {
 func (a *Agent) PowerOffSubscribe() error {
 	_, err := a.NATSConnection.QueueSubscribe("agent.poweroff."+a.Config.UUID, "scnorion-agent-management", func(msg *nats.Msg) {
 		log.Println("[INFO]: power off request received")
-		if err := msg.Respond([]byte("Power Off!")); err != nil {
-			log.Printf("[ERROR]: could not respond to agent power off message, reason: %v\n", err)
-			return
-		}
 
-		action := scnorion_nats.RebootOrRestart{}
+		action := power.PowerAction{}
 		if err := json.Unmarshal(msg.Data, &action); err != nil {
 			log.Printf("[ERROR]: could not unmarshal to agent power off message, reason: %v\n", err)
+			if err := msg.Respond([]byte(err.Error())); err != nil {
+				log.Printf("[ERROR]: could not respond to agent power off message, reason: %v\n", err)
+			}
 			return
 		}
 
+		// The console is told the user won't be warned
+		response := "Power Off!"
+		if action.NotifyUser && !power.NOTIFY_USER_SUPPORTED {
+			response += " User notification is not supported on this operating system"
+			log.Println("[INFO]: the user can't be notified before the power off on this operating system")
+		}
+		if err := msg.Respond([]byte(response)); err != nil {
+			log.Printf("[ERROR]: could not respond to agent power off message, reason: %v\n", err)
+		}
+
 		when := int(time.Until(action.Date).Seconds())
 		if when > 0 {
 			if err := exec.Command("cmd", "/C", "shutdown", "/s", "/t", strconv.Itoa(when)).Run(); err != nil {
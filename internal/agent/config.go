@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/scncore/scnorion-agent/internal/commands/telemetry"
 	scnorion_utils "github.com/scncore/utils"
 	"gopkg.in/ini.v1"
 )
@@ -34,6 +35,9 @@ type Config struct {
 	SiteID                   string
 	TenantID                 string
 	ScriptsRun               string
+	ArtifactSigningKey       string
+	TelemetryInterval        int
+	ReportConnections        bool
 }
 
 func (a *Agent) ReadConfig() error {
@@ -254,6 +258,32 @@ func (a *Agent) ReadConfig() error {
 		a.Config.ScriptsRun = key.String()
 	}
 
+	// Base64 ed25519 public key, if it's set artifacts must be signed
+	key, err = cfg.Section("Agent").GetKey("ArtifactSigningKey")
+	if err == nil {
+		a.Config.ArtifactSigningKey = key.String()
+	}
+
+	// Seconds between telemetry samples, 0 disables the sampler
+	a.Config.TelemetryInterval = telemetry.DEFAULT_SAMPLE_INTERVAL_SECONDS
+	key, err = cfg.Section("Agent").GetKey("TelemetryInterval")
+	if err == nil {
+		a.Config.TelemetryInterval, err = key.Int()
+		if err != nil {
+			log.Println("[ERROR]: could not parse TelemetryInterval")
+			a.Config.TelemetryInterval = telemetry.DEFAULT_SAMPLE_INTERVAL_SECONDS
+		}
+	}
+
+	// The established connections are only sent if they're requested
+	key, err = cfg.Section("Agent").GetKey("ReportConnections")
+	if err == nil {
+		a.Config.ReportConnections, err = key.Bool()
+		if err != nil {
+			log.Println("[ERROR]: could not parse ReportConnections")
+		}
+	}
+
 	log.Println("[INFO]: agent has read its settings from the INI file")
 	return nil
 }
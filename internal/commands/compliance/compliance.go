@@ -0,0 +1,372 @@
+package compliance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	CHECK_FILE_CONTENT     = "file_content"
+	CHECK_FILE_PERMISSIONS = "file_permissions"
+	CHECK_SYSCTL           = "sysctl"
+	CHECK_PACKAGE          = "package"
+	CHECK_SERVICE          = "service"
+	CHECK_KERNEL_MODULE    = "kernel_module"
+	CHECK_SSHD_OPTION      = "sshd_option"
+)
+
+const (
+	RESULT_PASS           = "pass"
+	RESULT_FAIL           = "fail"
+	RESULT_NOT_APPLICABLE = "not_applicable"
+	RESULT_ERROR          = "error"
+)
+
+const (
+	OPERATOR_EQUAL     = "eq"
+	OPERATOR_NOT_EQUAL = "ne"
+	OPERATOR_GTE       = "gte"
+	OPERATOR_LTE       = "lte"
+	OPERATOR_MATCH     = "match"
+)
+
+const (
+	STATE_INSTALLED   = "installed"
+	STATE_ABSENT      = "absent"
+	STATE_ACTIVE      = "active"
+	STATE_INACTIVE    = "inactive"
+	STATE_ENABLED     = "enabled"
+	STATE_DISABLED    = "disabled"
+	STATE_MASKED      = "masked"
+	STATE_LOADED      = "loaded"
+	STATE_BLACKLISTED = "blacklisted"
+)
+
+// States allowed by each check type, packages must be installed and kernel modules disabled if it's empty
+var checkStates = map[string][]string{
+	CHECK_PACKAGE:       {"", STATE_INSTALLED, STATE_ABSENT},
+	CHECK_SERVICE:       {STATE_ACTIVE, STATE_INACTIVE, STATE_ENABLED, STATE_DISABLED, STATE_MASKED},
+	CHECK_KERNEL_MODULE: {"", STATE_DISABLED, STATE_BLACKLISTED, STATE_LOADED},
+}
+
+// The score weights each rule by its severity, rules without severity are medium
+var severityWeights = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// Evidence is truncated, file contents could be huge
+const MAX_EVIDENCE_LENGTH = 512
+
+const POLICY_FILE = "compliance_policy.json"
+
+type Check struct {
+	Type string `yaml:"type" json:"type"`
+	// Path of the file for file_content and file_permissions
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Pattern is a regular expression evaluated line by line, if Absent is true it must not be found
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Absent  bool   `yaml:"absent,omitempty" json:"absent,omitempty"`
+	// Mode is the most permissive mode allowed e.g 0640
+	Mode  string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Group string `yaml:"group,omitempty" json:"group,omitempty"`
+	// Key is the sysctl key or the sshd option
+	Key      string `yaml:"key,omitempty" json:"key,omitempty"`
+	Value    string `yaml:"value,omitempty" json:"value,omitempty"`
+	Operator string `yaml:"operator,omitempty" json:"operator,omitempty"`
+	// Name of the package, service or kernel module
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	State string `yaml:"state,omitempty" json:"state,omitempty"`
+}
+
+type Rule struct {
+	ID          string `yaml:"id" json:"id"`
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Severity    string `yaml:"severity,omitempty" json:"severity,omitempty"`
+	Check       Check  `yaml:"check" json:"check"`
+}
+
+type Policy struct {
+	ID      string `yaml:"id" json:"id"`
+	Name    string `yaml:"name" json:"name"`
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	Rules   []Rule `yaml:"rules" json:"rules"`
+}
+
+type RuleResult struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+	Evidence string `json:"evidence,omitempty"`
+}
+
+type ComplianceResult struct {
+	AgentID       string       `json:"agent_id"`
+	PolicyID      string       `json:"policy_id"`
+	PolicyName    string       `json:"policy_name"`
+	PolicyVersion string       `json:"policy_version,omitempty"`
+	Results       []RuleResult `json:"results"`
+	Passed        int          `json:"passed"`
+	Failed        int          `json:"failed"`
+	NotApplicable int          `json:"not_applicable"`
+	Errors        int          `json:"errors"`
+	// Score is the percentage of the applicable rules that passed, weighted by severity
+	Score       float64   `json:"score"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ParsePolicy accepts YAML or JSON, JSON is valid YAML. Unknown fields are rejected
+// so a typo doesn't turn into a check that always passes
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := Policy{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not parse the compliance policy, reason: %v", err)
+	}
+
+	ids := map[string]bool{}
+	for i, r := range p.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %d has no id", i+1)
+		}
+		if ids[r.ID] {
+			return nil, fmt.Errorf("rule %s is duplicated", r.ID)
+		}
+		ids[r.ID] = true
+
+		if r.Severity == "" {
+			p.Rules[i].Severity = "medium"
+		} else if _, ok := severityWeights[r.Severity]; !ok {
+			return nil, fmt.Errorf("rule %s has an unknown severity %s", r.ID, r.Severity)
+		}
+
+		if err := r.Check.validate(); err != nil {
+			return nil, fmt.Errorf("rule %s %v", r.ID, err)
+		}
+	}
+
+	return &p, nil
+}
+
+// validate checks the fields required by each type, a missing one would make the check pass or fail whatever the system has
+func (c *Check) validate() error {
+	switch c.Type {
+	case CHECK_FILE_CONTENT:
+		if c.Path == "" || c.Pattern == "" {
+			return errors.New("requires a path and a pattern")
+		}
+	case CHECK_FILE_PERMISSIONS:
+		if c.Path == "" || (c.Mode == "" && c.Owner == "" && c.Group == "") {
+			return errors.New("requires a path and a mode, an owner or a group")
+		}
+		if c.Mode != "" {
+			if _, err := strconv.ParseUint(c.Mode, 8, 32); err != nil {
+				return fmt.Errorf("has an invalid mode %s, it must be octal e.g 0640", c.Mode)
+			}
+		}
+	case CHECK_SYSCTL, CHECK_SSHD_OPTION:
+		if c.Key == "" || c.Value == "" {
+			return errors.New("requires a key and a value")
+		}
+	case CHECK_PACKAGE, CHECK_SERVICE, CHECK_KERNEL_MODULE:
+		if c.Name == "" {
+			return errors.New("requires a name")
+		}
+		if !slices.Contains(checkStates[c.Type], c.State) {
+			return fmt.Errorf("has an invalid state %q for a %s check", c.State, c.Type)
+		}
+	default:
+		return fmt.Errorf("has an unknown check type %s", c.Type)
+	}
+
+	switch c.Operator {
+	case "", OPERATOR_EQUAL, OPERATOR_NOT_EQUAL, OPERATOR_GTE, OPERATOR_LTE:
+	case OPERATOR_MATCH:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("has an invalid value to match, reason: %v", err)
+		}
+	default:
+		return fmt.Errorf("has an unknown operator %s", c.Operator)
+	}
+
+	if c.Pattern != "" {
+		if _, err := regexp.Compile(c.Pattern); err != nil {
+			return fmt.Errorf("has an invalid pattern, reason: %v", err)
+		}
+	}
+	return nil
+}
+
+// Evaluate runs every rule, a check that can't be run is an error and counts as not passed
+func Evaluate(p *Policy) *ComplianceResult {
+	result := ComplianceResult{
+		PolicyID:      p.ID,
+		PolicyName:    p.Name,
+		PolicyVersion: p.Version,
+		Results:       []RuleResult{},
+		EvaluatedAt:   time.Now(),
+	}
+
+	passedWeight, totalWeight := 0, 0
+	for _, r := range p.Rules {
+		status, evidence, err := evaluateCheck(r.Check)
+		if err != nil {
+			status, evidence = RESULT_ERROR, err.Error()
+		}
+		if len(evidence) > MAX_EVIDENCE_LENGTH {
+			evidence = evidence[:MAX_EVIDENCE_LENGTH] + "..."
+		}
+
+		result.Results = append(result.Results, RuleResult{ID: r.ID, Title: r.Title, Severity: r.Severity, Status: status, Evidence: evidence})
+
+		weight := severityWeights[r.Severity]
+		switch status {
+		case RESULT_PASS:
+			result.Passed++
+			passedWeight += weight
+			totalWeight += weight
+		case RESULT_FAIL:
+			result.Failed++
+			totalWeight += weight
+		case RESULT_ERROR:
+			result.Errors++
+			totalWeight += weight
+		default:
+			result.NotApplicable++
+		}
+	}
+
+	result.Score = 100
+	if totalWeight > 0 {
+		result.Score = float64(passedWeight*10000/totalWeight) / 100
+	}
+	return &result
+}
+
+// checkFileContent is the same on every operating system
+func checkFileContent(c Check) (string, string, error) {
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if c.Absent {
+			return RESULT_PASS, c.Path + " does not exist", nil
+		}
+		return RESULT_FAIL, c.Path + " does not exist", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if re.MatchString(line) {
+			if c.Absent {
+				return RESULT_FAIL, "found: " + strings.TrimSpace(line), nil
+			}
+			return RESULT_PASS, "found: " + strings.TrimSpace(line), nil
+		}
+	}
+
+	if c.Absent {
+		return RESULT_PASS, "pattern not found in " + c.Path, nil
+	}
+	return RESULT_FAIL, "pattern not found in " + c.Path, nil
+}
+
+// compare checks a value read from the system, numbers are compared as such with gte and lte
+func compare(actual, expected, operator string) (bool, error) {
+	actual = strings.Join(strings.Fields(actual), " ")
+	expected = strings.Join(strings.Fields(expected), " ")
+
+	switch operator {
+	case "", OPERATOR_EQUAL:
+		return strings.EqualFold(actual, expected), nil
+	case OPERATOR_NOT_EQUAL:
+		return !strings.EqualFold(actual, expected), nil
+	case OPERATOR_GTE, OPERATOR_LTE:
+		a, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return false, fmt.Errorf("value %s is not a number", actual)
+		}
+		e, err := strconv.ParseFloat(expected, 64)
+		if err != nil {
+			return false, fmt.Errorf("expected value %s is not a number", expected)
+		}
+		if operator == OPERATOR_GTE {
+			return a >= e, nil
+		}
+		return a <= e, nil
+	case OPERATOR_MATCH:
+		return regexp.MatchString(expected, actual)
+	default:
+		return false, fmt.Errorf("operator %s is not valid", operator)
+	}
+}
+
+func policyPath() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(ex), POLICY_FILE), nil
+}
+
+// SavePolicy keeps the policy so it's evaluated again with every report, a policy without rules removes it
+func SavePolicy(p *Policy) error {
+	path, err := policyPath()
+	if err != nil {
+		return err
+	}
+
+	if len(p.Rules) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadPolicy returns nil if no policy has been received
+func LoadPolicy() (*Policy, error) {
+	path, err := policyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
@@ -0,0 +1,90 @@
+package compliance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{
+			name: "valid",
+			policy: `id: cis
+name: CIS
+rules:
+  - id: ssh-root
+    title: Root login is disabled
+    check: {type: sshd_option, key: PermitRootLogin, value: "no"}
+  - id: shadow
+    title: Shadow is not readable
+    check: {type: file_permissions, path: /etc/shadow, mode: "0640"}
+  - id: telnet
+    title: Telnet is not installed
+    check: {type: package, name: telnet, state: absent}
+  - id: cramfs
+    title: cramfs is disabled
+    check: {type: kernel_module, name: cramfs}
+  - id: aslr
+    title: ASLR is enabled
+    check: {type: sysctl, key: kernel.randomize_va_space, value: "2", operator: gte}
+`,
+		},
+		{
+			name:   "json",
+			policy: `{"id": "cis", "name": "CIS", "rules": [{"id": "auditd", "title": "auditd runs", "check": {"type": "service", "name": "auditd", "state": "active"}}]}`,
+		},
+		{
+			name:   "unknown field",
+			policy: "id: cis\nrules:\n  - id: ssh\n    check: {type: sshd_option, key: PermitRootLogin, valeu: \"no\"}\n",
+			err:    "field valeu not found",
+		},
+		{
+			name:   "missing pattern",
+			policy: "id: cis\nrules:\n  - id: motd\n    check: {type: file_content, path: /etc/motd}\n",
+			err:    "requires a path and a pattern",
+		},
+		{
+			name:   "missing mode, owner and group",
+			policy: "id: cis\nrules:\n  - id: shadow\n    check: {type: file_permissions, path: /etc/shadow}\n",
+			err:    "requires a path and a mode",
+		},
+		{
+			name:   "missing value",
+			policy: "id: cis\nrules:\n  - id: aslr\n    check: {type: sysctl, key: kernel.randomize_va_space}\n",
+			err:    "requires a key and a value",
+		},
+		{
+			name:   "missing state",
+			policy: "id: cis\nrules:\n  - id: auditd\n    check: {type: service, name: auditd}\n",
+			err:    "invalid state",
+		},
+		{
+			name:   "invalid state",
+			policy: "id: cis\nrules:\n  - id: telnet\n    check: {type: package, name: telnet, state: removed}\n",
+			err:    "invalid state",
+		},
+		{
+			name:   "invalid operator",
+			policy: "id: cis\nrules:\n  - id: aslr\n    check: {type: sysctl, key: kernel.randomize_va_space, value: \"2\", operator: gt}\n",
+			err:    "unknown operator",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.policy))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && err == nil:
+				t.Errorf("expected error %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
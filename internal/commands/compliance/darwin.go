@@ -0,0 +1,11 @@
+//go:build darwin
+
+package compliance
+
+// Only file contents can be checked on this operating system
+func evaluateCheck(c Check) (string, string, error) {
+	if c.Type == CHECK_FILE_CONTENT {
+		return checkFileContent(c)
+	}
+	return RESULT_NOT_APPLICABLE, "check is not supported on this operating system", nil
+}
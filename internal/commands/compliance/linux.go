@@ -0,0 +1,446 @@
+//go:build linux
+
+package compliance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
+	"github.com/scncore/scnorion-agent/internal/commands/services"
+)
+
+var modprobeDirs = []string{"/etc/modprobe.d", "/run/modprobe.d", "/usr/local/lib/modprobe.d", "/usr/lib/modprobe.d", "/lib/modprobe.d"}
+
+// Unit file states as systemctl reports them, static, indirect, generated and transient units
+// have no install section to enable them
+var (
+	enabledUnitFileStates  = []string{"enabled", "enabled-runtime", "alias"}
+	disabledUnitFileStates = []string{"disabled", "masked", "masked-runtime"}
+	staticUnitFileStates   = []string{"static", "indirect", "generated", "transient"}
+)
+
+const SSHD_CONFIG = "/etc/ssh/sshd_config"
+
+func evaluateCheck(c Check) (string, string, error) {
+	switch c.Type {
+	case CHECK_FILE_CONTENT:
+		return checkFileContent(c)
+	case CHECK_FILE_PERMISSIONS:
+		return checkFilePermissions(c)
+	case CHECK_SYSCTL:
+		return checkSysctl(c)
+	case CHECK_PACKAGE:
+		return checkPackage(c)
+	case CHECK_SERVICE:
+		return checkService(c)
+	case CHECK_KERNEL_MODULE:
+		return checkKernelModule(c)
+	case CHECK_SSHD_OPTION:
+		return checkSshdOption(c)
+	default:
+		return "", "", fmt.Errorf("check type %s is not valid", c.Type)
+	}
+}
+
+func checkFilePermissions(c Check) (string, string, error) {
+	info, err := os.Stat(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RESULT_NOT_APPLICABLE, c.Path + " does not exist", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	owner, group := "", ""
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		owner = strconv.Itoa(int(st.Uid))
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+		group = strconv.Itoa(int(st.Gid))
+		if g, err := user.LookupGroupId(group); err == nil {
+			group = g.Name
+		}
+	}
+
+	mode := info.Mode().Perm() | info.Mode()&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky)
+	evidence := fmt.Sprintf("mode %04o owner %s group %s", unixMode(mode), owner, group)
+
+	if c.Mode != "" {
+		allowed, err := strconv.ParseUint(c.Mode, 8, 32)
+		if err != nil {
+			return "", "", fmt.Errorf("mode %s is not a valid octal mode", c.Mode)
+		}
+		if unixMode(mode)&^uint32(allowed) != 0 {
+			return RESULT_FAIL, evidence, nil
+		}
+	}
+	if c.Owner != "" && c.Owner != owner {
+		return RESULT_FAIL, evidence, nil
+	}
+	if c.Group != "" && c.Group != group {
+		return RESULT_FAIL, evidence, nil
+	}
+	return RESULT_PASS, evidence, nil
+}
+
+// unixMode converts the Go file mode to the octal mode used by chmod
+func unixMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= syscall.S_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= syscall.S_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= syscall.S_ISVTX
+	}
+	return m
+}
+
+// checkSysctl reads the running value from /proc/sys e.g net.ipv4.ip_forward
+func checkSysctl(c Check) (string, string, error) {
+	path := filepath.Join("/proc/sys", strings.ReplaceAll(c.Key, ".", "/"))
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return RESULT_NOT_APPLICABLE, c.Key + " does not exist in this kernel", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	value := strings.TrimSpace(string(data))
+	ok, err := compare(value, c.Value, c.Operator)
+	if err != nil {
+		return "", "", err
+	}
+
+	evidence := fmt.Sprintf("%s = %s", c.Key, value)
+	if ok {
+		return RESULT_PASS, evidence, nil
+	}
+	return RESULT_FAIL, evidence, nil
+}
+
+func checkPackage(c Check) (string, string, error) {
+	pm, err := packagemanager.System()
+	if err != nil {
+		return "", "", err
+	}
+
+	version, installed, err := installedPackageVersion(pm.Name(), c.Name)
+	if err != nil {
+		return "", "", err
+	}
+	evidence := fmt.Sprintf("%s is not installed", c.Name)
+	if installed {
+		evidence = fmt.Sprintf("%s %s is installed", c.Name, version)
+	}
+
+	if installed == (c.State != STATE_ABSENT) {
+		return RESULT_PASS, evidence, nil
+	}
+	return RESULT_FAIL, evidence, nil
+}
+
+// installedPackageVersion queries the package database, not the repositories. The query commands
+// exit with 1 for packages that are not installed, other failures are returned as errors
+func installedPackageVersion(manager, name string) (string, bool, error) {
+	var cmd *exec.Cmd
+	switch manager {
+	case "apt":
+		cmd = exec.Command("dpkg-query", "--show", "--showformat", "${db:Status-Status} ${Version}", name)
+	case "dnf", "zypper":
+		cmd = exec.Command("rpm", "--query", "--queryformat", "installed %{VERSION}-%{RELEASE}", name)
+	case "pacman":
+		cmd = exec.Command("pacman", "--query", name)
+	case "apk":
+		cmd = exec.Command("apk", "info", "--installed", "--verbose", name)
+	default:
+		return "", false, fmt.Errorf("package manager %s is not supported", manager)
+	}
+
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		stderr := string(exitErr.Stderr)
+		notInstalled := false
+		switch manager {
+		case "apt":
+			notInstalled = strings.Contains(stderr, "no packages found")
+		case "dnf", "zypper":
+			notInstalled = strings.Contains(string(out), "is not installed")
+		case "pacman":
+			notInstalled = strings.Contains(stderr, "was not found")
+		case "apk":
+			notInstalled = strings.TrimSpace(stderr) == ""
+		}
+		if notInstalled {
+			return "", false, nil
+		}
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("could not query the package %s, reason: %v", name, err)
+	}
+
+	fields := strings.Fields(string(out))
+	switch manager {
+	case "apt", "dnf", "zypper":
+		// dpkg keeps removed packages with their configuration files
+		if len(fields) < 2 || fields[0] != "installed" {
+			return "", false, nil
+		}
+		return fields[1], true, nil
+	case "pacman":
+		if len(fields) < 2 {
+			return "", false, fmt.Errorf("unexpected output querying the package %s: %s", name, out)
+		}
+		return fields[1], true, nil
+	default:
+		// apk prints name-version
+		if len(fields) == 0 {
+			return "", false, nil
+		}
+		return strings.TrimPrefix(fields[0], name+"-"), true, nil
+	}
+}
+
+// checkService passes for units that don't exist if they're expected to be stopped, disabled or masked
+func checkService(c Check) (string, string, error) {
+	unit := c.Name
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+
+	units, err := services.Show([]string{unit})
+	if err != nil {
+		return "", "", err
+	}
+	if len(units) == 0 || units[0].LoadState == "not-found" {
+		if c.State == STATE_ACTIVE || c.State == STATE_ENABLED {
+			return RESULT_FAIL, unit + " is not installed", nil
+		}
+		return RESULT_PASS, unit + " is not installed", nil
+	}
+
+	u := units[0]
+	evidence := fmt.Sprintf("%s is %s (%s) and %s", unit, u.ActiveState, u.SubState, u.UnitFileState)
+
+	if (c.State == STATE_ENABLED || c.State == STATE_DISABLED) && slices.Contains(staticUnitFileStates, u.UnitFileState) {
+		return RESULT_NOT_APPLICABLE, evidence + ", it can't be enabled or disabled", nil
+	}
+
+	ok := false
+	switch c.State {
+	case STATE_ACTIVE:
+		ok = u.ActiveState == "active"
+	case STATE_INACTIVE:
+		ok = u.ActiveState != "active"
+	case STATE_ENABLED:
+		ok = slices.Contains(enabledUnitFileStates, u.UnitFileState)
+	case STATE_DISABLED:
+		ok = slices.Contains(disabledUnitFileStates, u.UnitFileState)
+	case STATE_MASKED:
+		ok = u.UnitFileState == "masked" || u.UnitFileState == "masked-runtime"
+	default:
+		return "", "", fmt.Errorf("service state %s is not valid", c.State)
+	}
+
+	if ok {
+		return RESULT_PASS, evidence, nil
+	}
+	return RESULT_FAIL, evidence, nil
+}
+
+// checkKernelModule expects by default a module that is not loaded and can't be loaded, either
+// blacklisted or with its install command replaced e.g install cramfs /bin/false
+func checkKernelModule(c Check) (string, string, error) {
+	name := strings.ReplaceAll(c.Name, "-", "_")
+
+	loaded, err := isModuleLoaded(name)
+	if err != nil {
+		return "", "", err
+	}
+	disabledBy := moduleDisabledBy(name)
+
+	evidence := fmt.Sprintf("%s is not loaded", c.Name)
+	if loaded {
+		evidence = fmt.Sprintf("%s is loaded", c.Name)
+	}
+	if disabledBy != "" {
+		evidence += ", disabled in " + disabledBy
+	}
+
+	ok := false
+	switch c.State {
+	case STATE_LOADED:
+		ok = loaded
+	case "", STATE_DISABLED, STATE_BLACKLISTED:
+		ok = !loaded && disabledBy != ""
+	default:
+		return "", "", fmt.Errorf("kernel module state %s is not valid", c.State)
+	}
+
+	if ok {
+		return RESULT_PASS, evidence, nil
+	}
+	return RESULT_FAIL, evidence, nil
+}
+
+func isModuleLoaded(name string) (bool, error) {
+	// Kernels built without module support have no /proc/modules
+	data, err := os.ReadFile("/proc/modules")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == name {
+			return true, nil
+		}
+	}
+	// Modules built into the kernel are not listed but can't be disabled either
+	_, err = os.Stat(filepath.Join("/sys/module", name))
+	return err == nil, nil
+}
+
+// moduleDisabledBy returns the modprobe file that blacklists the module or replaces its install command
+func moduleDisabledBy(name string) string {
+	for _, dir := range modprobeDirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.conf"))
+		if err != nil {
+			continue
+		}
+		for _, path := range files {
+			f, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+
+			disabled := false
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) < 2 || strings.ReplaceAll(fields[1], "-", "_") != name {
+					continue
+				}
+				if fields[0] == "blacklist" || (fields[0] == "install" && len(fields) > 2 && (strings.HasSuffix(fields[2], "/false") || strings.HasSuffix(fields[2], "/true"))) {
+					disabled = true
+					break
+				}
+			}
+			f.Close()
+
+			if disabled {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// checkSshdOption prefers the effective configuration printed by sshd -T,
+// the configuration files are read if sshd can't be run
+func checkSshdOption(c Check) (string, string, error) {
+	if _, err := os.Stat(SSHD_CONFIG); errors.Is(err, os.ErrNotExist) {
+		return RESULT_NOT_APPLICABLE, "OpenSSH server is not installed", nil
+	}
+
+	key := strings.ToLower(c.Key)
+	options := sshdEffectiveOptions()
+	if options == nil {
+		options = map[string]string{}
+		readSshdConfig(SSHD_CONFIG, options, 0)
+	}
+
+	value, found := options[key]
+	if !found {
+		return RESULT_FAIL, c.Key + " is not set", nil
+	}
+
+	ok, err := compare(value, c.Value, c.Operator)
+	if err != nil {
+		return "", "", err
+	}
+
+	evidence := fmt.Sprintf("%s %s", c.Key, value)
+	if ok {
+		return RESULT_PASS, evidence, nil
+	}
+	return RESULT_FAIL, evidence, nil
+}
+
+func sshdEffectiveOptions() map[string]string {
+	path, err := exec.LookPath("sshd")
+	if err != nil {
+		path = "/usr/sbin/sshd"
+	}
+
+	out, err := exec.Command(path, "-T").Output()
+	if err != nil {
+		return nil
+	}
+
+	options := map[string]string{}
+	for line := range strings.SplitSeq(string(out), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found {
+			continue
+		}
+		// Options like hostkey can be repeated, we keep them all
+		if previous, ok := options[key]; ok {
+			value = previous + " " + value
+		}
+		options[key] = value
+	}
+	return options
+}
+
+// readSshdConfig keeps the first value of each option as sshd does,
+// Match blocks are skipped as they only apply to some connections
+func readSshdConfig(path string, options map[string]string, depth int) {
+	if depth > 5 {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		key := strings.ToLower(fields[0])
+		switch key {
+		case "match":
+			return
+		case "include":
+			for _, pattern := range fields[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join("/etc/ssh", pattern)
+				}
+				includes, _ := filepath.Glob(pattern)
+				for _, include := range includes {
+					readSshdConfig(include, options, depth+1)
+				}
+			}
+		default:
+			if _, ok := options[key]; !ok {
+				options[key] = strings.Join(fields[1:], " ")
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package deploy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Package IDs of artifacts use this prefix so UninstallPackage knows they're not in a repository
+const ARTIFACT_PREFIX = "artifact:"
+
+const ARTIFACT_DOWNLOAD_TIMEOUT = 30 * time.Minute
+
+const (
+	ARTIFACT_DEB      = "deb"
+	ARTIFACT_RPM      = "rpm"
+	ARTIFACT_APPIMAGE = "appimage"
+	ARTIFACT_TARGZ    = "tar.gz"
+)
+
+// ArtifactDeployment is sent by the console to install a file that is not published in any repository
+type ArtifactDeployment struct {
+	PackageID   string `json:"package_id"`
+	PackageName string `json:"package_name"`
+	URL         string `json:"url,omitempty"`
+	Bucket      string `json:"bucket,omitempty"`
+	Object      string `json:"object,omitempty"`
+	SHA256      string `json:"sha256"`
+	Signature   string `json:"signature,omitempty"`
+	Type        string `json:"type,omitempty"`
+	TargetPath  string `json:"target_path,omitempty"`
+}
+
+// InstalledArtifact keeps what was installed so the artifact can be removed later
+type InstalledArtifact struct {
+	PackageID   string    `json:"package_id"`
+	Type        string    `json:"type"`
+	File        string    `json:"file"`
+	Package     string    `json:"package,omitempty"`
+	Paths       []string  `json:"paths,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ObjectOpener reads an artifact from the NATS object store, the agent owns the connection
+type ObjectOpener func(ctx context.Context, bucket, object string) (io.ReadCloser, error)
+
+var artifactsMutex sync.Mutex
+
+func IsArtifact(packageID string) bool {
+	return strings.HasPrefix(packageID, ARTIFACT_PREFIX)
+}
+
+// ArtifactsDir is the cache where artifacts are downloaded, it's a folder next to the agent executable
+func ArtifactsDir() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(ex), "artifacts"), nil
+}
+
+// GetArtifact returns the path of the verified artifact, it's only downloaded if it's not in the cache
+func GetArtifact(artifact *ArtifactDeployment, open ObjectOpener, publicKey string) (string, error) {
+	if artifact.SHA256 == "" {
+		return "", errors.New("the artifact has no SHA-256 hash")
+	}
+
+	if artifact.Type == "" {
+		artifact.Type = GuessArtifactType(artifact.URL + artifact.Object)
+	}
+	if artifact.Type == "" {
+		return "", errors.New("could not guess the artifact type from its name")
+	}
+
+	dir, err := ArtifactsDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	hash := strings.ToLower(artifact.SHA256)
+	path := filepath.Join(dir, hash+"."+artifact.Type)
+
+	if sum, err := fileSHA256(path); err == nil && sum == hash {
+		log.Printf("[INFO]: artifact %s found in cache", artifact.PackageID)
+	} else {
+		if err := downloadArtifact(artifact, open, path); err != nil {
+			return "", err
+		}
+	}
+
+	if err := verifyArtifactSignature(artifact, publicKey); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// GuessArtifactType uses the file extension
+func GuessArtifactType(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.IndexAny(name, "?#"); i > 0 {
+		name = name[:i]
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".deb"):
+		return ARTIFACT_DEB
+	case strings.HasSuffix(name, ".rpm"):
+		return ARTIFACT_RPM
+	case strings.HasSuffix(name, ".appimage"):
+		return ARTIFACT_APPIMAGE
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ARTIFACT_TARGZ
+	default:
+		return ""
+	}
+}
+
+// downloadArtifact writes to a temporary file that is only renamed if the hash matches
+func downloadArtifact(artifact *ArtifactDeployment, open ObjectOpener, path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ARTIFACT_DOWNLOAD_TIMEOUT)
+	defer cancel()
+
+	var src io.ReadCloser
+	switch {
+	case artifact.Bucket != "" && artifact.Object != "":
+		if open == nil {
+			return errors.New("the object store is not available")
+		}
+		r, err := open(ctx, artifact.Bucket, artifact.Object)
+		if err != nil {
+			return fmt.Errorf("could not get %s from object store %s, reason: %v", artifact.Object, artifact.Bucket, err)
+		}
+		src = r
+	case strings.HasPrefix(artifact.URL, "https://") || strings.HasPrefix(artifact.URL, "http://"):
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, artifact.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("could not download %s, status: %s", artifact.URL, resp.Status)
+		}
+		src = resp.Body
+	default:
+		return errors.New("the artifact has no valid URL or object store location")
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	if sum != strings.ToLower(artifact.SHA256) {
+		return fmt.Errorf("SHA-256 mismatch, expected %s got %s", artifact.SHA256, sum)
+	}
+
+	log.Printf("[INFO]: artifact %s has been downloaded and its hash verified", artifact.PackageID)
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// verifyArtifactSignature checks the ed25519 signature of the SHA-256 hex digest using the key
+// set in the agent configuration. If a key is configured unsigned artifacts are rejected
+func verifyArtifactSignature(artifact *ArtifactDeployment, publicKey string) error {
+	if publicKey == "" {
+		if artifact.Signature != "" {
+			return errors.New("the artifact is signed but the agent has no signing key configured")
+		}
+		return nil
+	}
+
+	if artifact.Signature == "" {
+		return errors.New("the artifact is not signed and the agent requires signed artifacts")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("the artifact signing key is not a valid ed25519 public key")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(artifact.Signature)
+	if err != nil {
+		return fmt.Errorf("could not decode the artifact signature, reason: %v", err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), []byte(strings.ToLower(artifact.SHA256)), signature) {
+		return errors.New("the artifact signature is not valid")
+	}
+
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func readInstalledArtifacts() (map[string]InstalledArtifact, error) {
+	artifacts := map[string]InstalledArtifact{}
+
+	dir, err := ArtifactsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "installed.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return artifacts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &artifacts); err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+func saveInstalledArtifacts(artifacts map[string]InstalledArtifact) error {
+	dir, err := ArtifactsDir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(artifacts, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "installed.json"), data, 0600)
+}
+
+func TrackArtifact(installed InstalledArtifact) error {
+	artifactsMutex.Lock()
+	defer artifactsMutex.Unlock()
+
+	artifacts, err := readInstalledArtifacts()
+	if err != nil {
+		return err
+	}
+	artifacts[installed.PackageID] = installed
+	return saveInstalledArtifacts(artifacts)
+}
+
+// UntrackArtifact removes the artifact from the installed list and from the cache
+func UntrackArtifact(packageID string) error {
+	artifactsMutex.Lock()
+	defer artifactsMutex.Unlock()
+
+	artifacts, err := readInstalledArtifacts()
+	if err != nil {
+		return err
+	}
+
+	if installed, ok := artifacts[packageID]; ok {
+		if err := os.Remove(installed.File); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[ERROR]: could not remove cached artifact %s, reason: %v", installed.File, err)
+		}
+	}
+
+	delete(artifacts, packageID)
+	return saveInstalledArtifacts(artifacts)
+}
+
+func GetInstalledArtifact(packageID string) (InstalledArtifact, error) {
+	artifactsMutex.Lock()
+	defer artifactsMutex.Unlock()
+
+	artifacts, err := readInstalledArtifacts()
+	if err != nil {
+		return InstalledArtifact{}, err
+	}
+
+	installed, ok := artifacts[packageID]
+	if !ok {
+		return InstalledArtifact{}, fmt.Errorf("artifact %s is not installed", packageID)
+	}
+	return installed, nil
+}
@@ -0,0 +1,349 @@
+//go:build linux
+
+package deploy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
+)
+
+const DEFAULT_APPIMAGES_DIR = "/opt/appimages"
+const DEFAULT_ARCHIVES_DIR = "/opt"
+
+var safeNameRegexp = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func InstallArtifact(artifact *ArtifactDeployment, open ObjectOpener, publicKey string) error {
+	if !IsArtifact(artifact.PackageID) {
+		artifact.PackageID = ARTIFACT_PREFIX + artifact.PackageID
+	}
+
+	log.Printf("[INFO]: received a request to install artifact %s", artifact.PackageID)
+
+	path, err := GetArtifact(artifact, open, publicKey)
+	if err != nil {
+		log.Printf("[ERROR]: could not get artifact %s, reason: %v", artifact.PackageID, err)
+		return &DeployError{Source: "artifact", PackageID: artifact.PackageID, ExitCode: -1, Message: err.Error()}
+	}
+
+	// Files of a previous install of the same artifact can be overwritten, any other existing file can't
+	owned := map[string]bool{}
+	if previous, err := GetInstalledArtifact(artifact.PackageID); err == nil {
+		for _, p := range previous.Paths {
+			owned[p] = true
+		}
+	}
+
+	installed := InstalledArtifact{
+		PackageID:   artifact.PackageID,
+		Type:        artifact.Type,
+		File:        path,
+		InstalledAt: time.Now(),
+	}
+
+	switch artifact.Type {
+	case ARTIFACT_DEB:
+		installed.Package, err = installPackageFile(path, "apt", []string{"dpkg-deb", "--field", path, "Package"}, []string{"dpkg", "--install", path})
+	case ARTIFACT_RPM:
+		installed.Package, err = installPackageFile(path, "", []string{"rpm", "--query", "--package", "--queryformat", "%{NAME}", path}, []string{"rpm", "--upgrade", "--replacepkgs", path})
+	case ARTIFACT_APPIMAGE:
+		installed.Paths, err = installAppImage(path, artifact, owned)
+	case ARTIFACT_TARGZ:
+		installed.Paths, err = extractTarGz(path, artifactTarget(artifact, DEFAULT_ARCHIVES_DIR, ""), owned)
+	default:
+		err = fmt.Errorf("artifact type %s is not supported", artifact.Type)
+	}
+
+	if err != nil {
+		log.Printf("[ERROR]: could not install artifact %s, reason: %v", artifact.PackageID, err)
+		return newDeployError("artifact", artifact.PackageID, err)
+	}
+
+	if err := TrackArtifact(installed); err != nil {
+		log.Printf("[ERROR]: could not save installed artifact %s, it can't be uninstalled later, reason: %v", artifact.PackageID, err)
+	}
+
+	log.Printf("[INFO]: artifact %s has been installed", artifact.PackageID)
+
+	return nil
+}
+
+func UninstallArtifact(packageID string) error {
+	log.Printf("[INFO]: received a request to remove artifact %s", packageID)
+
+	installed, err := GetInstalledArtifact(packageID)
+	if err != nil {
+		return newDeployError("artifact", packageID, err)
+	}
+
+	switch installed.Type {
+	case ARTIFACT_DEB, ARTIFACT_RPM:
+		err = removePackage(installed)
+	default:
+		err = removePaths(installed.Paths)
+	}
+	if err != nil {
+		log.Printf("[ERROR]: could not remove artifact %s, reason: %v", packageID, err)
+		return newDeployError("artifact", packageID, err)
+	}
+
+	if err := UntrackArtifact(packageID); err != nil {
+		log.Printf("[ERROR]: could not remove artifact %s from the installed list, reason: %v", packageID, err)
+	}
+
+	log.Printf("[INFO]: artifact %s has been removed", packageID)
+
+	return nil
+}
+
+// installPackageFile prefers the system package manager as it resolves the dependencies,
+// apt, dnf and zypper accept a path instead of a package name
+func installPackageFile(path, expectedManager string, nameCmd []string, fallbackCmd []string) (string, error) {
+	out, err := exec.Command(nameCmd[0], nameCmd[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read the package name from %s, reason: %v", path, err)
+	}
+	name := strings.TrimSpace(string(out))
+
+	pm, err := packagemanager.System()
+	if err == nil && isManagerForPackageFile(pm.Name(), expectedManager) {
+		return name, pm.Install(path)
+	}
+
+	if out, err := exec.Command(fallbackCmd[0], fallbackCmd[1:]...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%s failed, reason: %v, output: %s", fallbackCmd[0], err, strings.TrimSpace(string(out)))
+	}
+	return name, nil
+}
+
+func isManagerForPackageFile(manager, expectedManager string) bool {
+	if expectedManager != "" {
+		return manager == expectedManager
+	}
+	// rpm files
+	return manager == "dnf" || manager == "zypper"
+}
+
+func removePackage(installed InstalledArtifact) error {
+	if installed.Package == "" {
+		return errors.New("the package name of the artifact is unknown")
+	}
+
+	pm, err := packagemanager.System()
+	if err == nil {
+		return pm.Remove(installed.Package)
+	}
+
+	cmd := exec.Command("rpm", "--erase", installed.Package)
+	if installed.Type == ARTIFACT_DEB {
+		cmd = exec.Command("dpkg", "--remove", installed.Package)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed, reason: %v, output: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// artifactTarget returns the target path chosen by the console or a folder named after the package
+func artifactTarget(artifact *ArtifactDeployment, defaultDir, suffix string) string {
+	if artifact.TargetPath != "" {
+		return filepath.Clean(artifact.TargetPath)
+	}
+
+	name := artifact.PackageName
+	if name == "" {
+		name = strings.TrimPrefix(artifact.PackageID, ARTIFACT_PREFIX)
+	}
+	return filepath.Join(defaultDir, safeNameRegexp.ReplaceAllString(name, "_")+suffix)
+}
+
+func installAppImage(path string, artifact *ArtifactDeployment, owned map[string]bool) ([]string, error) {
+	target := artifactTarget(artifact, DEFAULT_APPIMAGES_DIR, ".AppImage")
+
+	if _, err := os.Lstat(target); err == nil && !owned[target] {
+		return nil, fmt.Errorf("%s already exists and it doesn't belong to the artifact", target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return nil, err
+	}
+
+	return []string{target}, dst.Close()
+}
+
+// extractTarGz extracts the archive inside target and returns the files and the folders it created,
+// entries that would be written outside the target are rejected. Existing files are never overwritten
+// unless they're owned by a previous install of the artifact, as they would be removed on uninstall
+func extractTarGz(path, target string, owned map[string]bool) (paths []string, err error) {
+	created := []string{}
+
+	// Nothing is left behind if the archive can't be fully extracted
+	defer func() {
+		if err != nil {
+			if removeErr := removePaths(created); removeErr != nil {
+				log.Printf("[ERROR]: could not clean up the extracted files, reason: %v", removeErr)
+			}
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	mkdir := func(dir string) error {
+		missing := []string{}
+		for d := dir; d != target && strings.HasPrefix(d, target); d = filepath.Dir(d) {
+			if _, err := os.Lstat(d); err == nil {
+				break
+			}
+			missing = append(missing, d)
+		}
+		if _, err := os.Lstat(target); err != nil {
+			missing = append(missing, target)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		slices.Reverse(missing)
+		created = append(created, missing...)
+		return nil
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return created, err
+		}
+
+		dest := filepath.Join(target, hdr.Name)
+		if dest != target && !strings.HasPrefix(dest, target+string(os.PathSeparator)) {
+			return created, fmt.Errorf("archive entry %s is outside the target folder", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := mkdir(dest); err != nil {
+				return created, err
+			}
+		case tar.TypeReg:
+			if err := mkdir(filepath.Dir(dest)); err != nil {
+				return created, err
+			}
+			flags := os.O_CREATE | os.O_WRONLY | os.O_EXCL
+			if owned[dest] {
+				flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+			}
+			out, err := os.OpenFile(dest, flags, os.FileMode(hdr.Mode).Perm())
+			if errors.Is(err, os.ErrExist) {
+				return created, fmt.Errorf("%s already exists and it doesn't belong to the artifact", dest)
+			}
+			if err != nil {
+				return created, err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return created, err
+			}
+			if err := out.Close(); err != nil {
+				return created, err
+			}
+			created = append(created, dest)
+		case tar.TypeSymlink:
+			// Links can only point inside the target folder
+			linkTarget := hdr.Linkname
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(dest), linkTarget)
+			}
+			if !strings.HasPrefix(filepath.Clean(linkTarget), target+string(os.PathSeparator)) {
+				return created, fmt.Errorf("archive link %s points outside the target folder", hdr.Name)
+			}
+			if err := mkdir(filepath.Dir(dest)); err != nil {
+				return created, err
+			}
+			if owned[dest] {
+				if err := os.Remove(dest); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return created, err
+				}
+			}
+			if err := os.Symlink(hdr.Linkname, dest); errors.Is(err, os.ErrExist) {
+				return created, fmt.Errorf("%s already exists and it doesn't belong to the artifact", dest)
+			} else if err != nil {
+				return created, err
+			}
+			created = append(created, dest)
+		default:
+			log.Printf("[INFO]: archive entry %s has been skipped, its type is not supported", hdr.Name)
+		}
+	}
+
+	return created, nil
+}
+
+// removePaths removes files first and then the folders, from the deepest one, if they're empty
+func removePaths(paths []string) error {
+	dirs := []string{}
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, p)
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+
+	slices.SortFunc(dirs, func(a, b string) int { return len(b) - len(a) })
+	for _, d := range dirs {
+		if err := os.Remove(d); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[INFO]: folder %s has not been removed, reason: %v", d, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package deploy
+
+import "errors"
+
+func InstallArtifact(artifact *ArtifactDeployment, open ObjectOpener, publicKey string) error {
+	return errors.New("artifact deployment is not supported on this platform")
+}
+
+func UninstallArtifact(packageID string) error {
+	return errors.New("artifact deployment is not supported on this platform")
+}
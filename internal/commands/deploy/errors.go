@@ -0,0 +1,20 @@
+package deploy
+
+import "fmt"
+
+// DeployError is sent to the console along with the deploy result so it can show why a deployment failed
+type DeployError struct {
+	Source    string `json:"source,omitempty"`
+	PackageID string `json:"package_id"`
+	Command   string `json:"command,omitempty"`
+	ExitCode  int    `json:"exit_code"`
+	Output    string `json:"output,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (e *DeployError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("could not deploy %s using %s: %s", e.PackageID, e.Source, e.Message)
+	}
+	return fmt.Sprintf("could not deploy %s: %s", e.PackageID, e.Message)
+}
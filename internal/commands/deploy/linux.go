@@ -0,0 +1,115 @@
+//go:build linux
+
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
+)
+
+// Packages offered by the console are Flathub apps so flatpak is used when the ID has no source
+const DEFAULT_PACKAGE_MANAGER = "flatpak"
+
+// Package IDs can carry the source as a prefix e.g apt:openssh-server, flatpak:org.libreoffice.LibreOffice
+var packageSources = []string{"flatpak", "apt", "dnf", "zypper", "pacman", "apk", "snap"}
+
+func InstallPackage(packageID string) error {
+	source, name := parsePackageID(packageID)
+	log.Printf("[INFO]: received a request to install package %s using %s", name, source)
+
+	pm, err := packagemanager.Get(source)
+	if err != nil {
+		log.Printf("[ERROR]: could not find a package manager to install %s, reason: %v", name, err)
+		return newDeployError(source, name, err)
+	}
+
+	if err := pm.Install(name); err != nil {
+		log.Printf("[ERROR]: found and error with %s install command, reason %v", source, err)
+		return newDeployError(source, name, err)
+	}
+
+	log.Printf("[INFO]: %s has installed an application: %s", source, name)
+
+	return nil
+}
+
+func UpdatePackage(packageID string) error {
+	source, name := parsePackageID(packageID)
+	log.Printf("[INFO]: received a request to update package %s using %s", name, source)
+
+	pm, err := packagemanager.Get(source)
+	if err != nil {
+		log.Printf("[ERROR]: could not find a package manager to update %s, reason: %v", name, err)
+		return newDeployError(source, name, err)
+	}
+
+	if err := pm.Update(name); err != nil {
+		log.Printf("[ERROR]: found and error with %s update command, reason %v", source, err)
+		return newDeployError(source, name, err)
+	}
+
+	log.Printf("[INFO]: %s has updated an application: %s", source, name)
+
+	return nil
+}
+
+func UninstallPackage(packageID string) error {
+	if IsArtifact(packageID) {
+		return UninstallArtifact(packageID)
+	}
+
+	source, name := parsePackageID(packageID)
+	log.Printf("[INFO]: received a request to remove package %s using %s", name, source)
+
+	pm, err := packagemanager.Get(source)
+	if err != nil {
+		log.Printf("[ERROR]: could not find a package manager to remove %s, reason: %v", name, err)
+		return newDeployError(source, name, err)
+	}
+
+	if err := pm.Remove(name); err != nil {
+		log.Printf("[ERROR]: found and error with %s remove command, reason %v", source, err)
+		return newDeployError(source, name, err)
+	}
+
+	log.Printf("[INFO]: %s has removed an application: %s", source, name)
+
+	return nil
+}
+
+// parsePackageID splits the source prefix from the package name, flatpak IDs
+// never contain a colon so IDs without a known prefix are flatpak apps
+func parsePackageID(packageID string) (string, string) {
+	if source, name, found := strings.Cut(packageID, ":"); found {
+		for _, s := range packageSources {
+			if s == source {
+				return source, name
+			}
+		}
+	}
+	return DEFAULT_PACKAGE_MANAGER, packageID
+}
+
+func newDeployError(source, packageID string, err error) *DeployError {
+	deployErr := &DeployError{
+		Source:    source,
+		PackageID: packageID,
+		ExitCode:  -1,
+		Message:   err.Error(),
+	}
+
+	// Lock timeouts are command errors too but no command has been run
+	var cmdErr *packagemanager.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Command != "" {
+		deployErr.Command = cmdErr.Command
+		deployErr.ExitCode = cmdErr.ExitCode
+		deployErr.Output = cmdErr.Output
+		deployErr.Message = fmt.Sprintf("%s exited with code %d", source, cmdErr.ExitCode)
+	}
+
+	return deployErr
+}
@@ -0,0 +1,9 @@
+//go:build darwin
+
+package discovery
+
+import "errors"
+
+func Discover(req DiscoveryRequest) (*DiscoveryResult, error) {
+	return nil, errors.New("network discovery is only supported by the Linux agent")
+}
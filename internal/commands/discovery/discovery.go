@@ -0,0 +1,62 @@
+package discovery
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DEFAULT_RATE_PER_SECOND      = 100
+	DEFAULT_TIMEOUT_MS           = 1000
+	DEFAULT_MAX_HOSTS_PER_SUBNET = 1024
+)
+
+// Common ports used to find devices that don't answer ICMP
+var DefaultPorts = []int{21, 22, 23, 25, 53, 80, 135, 139, 443, 445, 515, 631, 3389, 5900, 8080, 9100}
+
+type DiscoveryRequest struct {
+	Ports             []int `json:"ports,omitempty"`
+	RatePerSecond     int   `json:"rate_per_second,omitempty"`
+	TimeoutMs         int   `json:"timeout_ms,omitempty"`
+	MaxHostsPerSubnet int   `json:"max_hosts_per_subnet,omitempty"`
+}
+
+type DiscoveredDevice struct {
+	IP           string   `json:"ip"`
+	MACAddress   string   `json:"mac_address"`
+	Vendor       string   `json:"vendor"`
+	Hostname     string   `json:"hostname"`
+	OpenPorts    []int    `json:"open_ports"`
+	Interface    string   `json:"interface"`
+	DiscoveredBy []string `json:"discovered_by"`
+}
+
+type DiscoveryResult struct {
+	AgentID    string             `json:"agent_id"`
+	Subnets    []string           `json:"subnets"`
+	Devices    []DiscoveredDevice `json:"devices"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt time.Time          `json:"finished_at"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// Only one discovery can be run at a time
+var running atomic.Bool
+
+func (req *DiscoveryRequest) setDefaults() {
+	if len(req.Ports) == 0 {
+		req.Ports = DefaultPorts
+	}
+
+	if req.RatePerSecond <= 0 {
+		req.RatePerSecond = DEFAULT_RATE_PER_SECOND
+	}
+
+	if req.TimeoutMs <= 0 {
+		req.TimeoutMs = DEFAULT_TIMEOUT_MS
+	}
+
+	if req.MaxHostsPerSubnet <= 0 {
+		req.MaxHostsPerSubnet = DEFAULT_MAX_HOSTS_PER_SUBNET
+	}
+}
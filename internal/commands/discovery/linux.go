@@ -0,0 +1,326 @@
+//go:build linux
+
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scncore/scnorion-agent/internal/commands/report"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+type subnet struct {
+	iface string
+	ip    net.IP
+	net   *net.IPNet
+}
+
+func Discover(req DiscoveryRequest) (*DiscoveryResult, error) {
+	if !running.CompareAndSwap(false, true) {
+		return nil, errors.New("a network discovery is already running")
+	}
+	defer running.Store(false)
+
+	req.setDefaults()
+
+	result := DiscoveryResult{
+		Subnets:   []string{},
+		Devices:   []DiscoveredDevice{},
+		StartedAt: time.Now(),
+	}
+
+	subnets, err := getAttachedSubnets()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every probe waits for a tick so we never exceed the requested rate
+	limiter := time.NewTicker(time.Second / time.Duration(req.RatePerSecond))
+	defer limiter.Stop()
+
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	errMessages := []string{}
+
+	for _, s := range subnets {
+		hosts := getSubnetHosts(s)
+		if len(hosts) > req.MaxHostsPerSubnet {
+			errMessages = append(errMessages, fmt.Sprintf("subnet %s has more than %d hosts and has been skipped", s.net.String(), req.MaxHostsPerSubnet))
+			continue
+		}
+		result.Subnets = append(result.Subnets, s.net.String())
+		log.Printf("[INFO]: network discovery started for subnet %s on %s", s.net.String(), s.iface)
+
+		alive, err := pingSweep(hosts, limiter, timeout)
+		if err != nil {
+			log.Printf("[ERROR]: could not run ICMP probes, reason: %v", err)
+		}
+
+		openPorts := tcpSweep(hosts, req.Ports, limiter, timeout)
+
+		// The kernel has resolved the neighbours we've probed so the ARP table is up to date now
+		arpTable, err := readARPTable(s.iface)
+		if err != nil {
+			log.Printf("[ERROR]: could not read ARP table, reason: %v", err)
+		}
+
+		for _, h := range hosts {
+			ip := h.String()
+			device := DiscoveredDevice{
+				IP:           ip,
+				Interface:    s.iface,
+				OpenPorts:    []int{},
+				DiscoveredBy: []string{},
+			}
+
+			if mac, ok := arpTable[ip]; ok {
+				device.MACAddress = mac
+				device.DiscoveredBy = append(device.DiscoveredBy, "arp")
+			}
+
+			if alive[ip] {
+				device.DiscoveredBy = append(device.DiscoveredBy, "icmp")
+			}
+
+			if ports, ok := openPorts[ip]; ok {
+				slices.Sort(ports)
+				device.OpenPorts = ports
+				device.DiscoveredBy = append(device.DiscoveredBy, "tcp")
+			}
+
+			if len(device.DiscoveredBy) == 0 {
+				continue
+			}
+
+			device.Vendor = getVendor(device.MACAddress)
+			device.Hostname = reverseLookup(ip, timeout)
+			result.Devices = append(result.Devices, device)
+		}
+	}
+
+	result.Error = strings.Join(errMessages, ", ")
+	result.FinishedAt = time.Now()
+	log.Printf("[INFO]: network discovery has found %d devices in %v", len(result.Devices), result.FinishedAt.Sub(result.StartedAt))
+
+	return &result, nil
+}
+
+func getAttachedSubnets() ([]subnet, error) {
+	subnets := []subnet{}
+
+	adapters, err := report.GetActiveNetworkAdapters()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range adapters {
+		if n.Addresses == "" {
+			continue
+		}
+
+		addresses := strings.Split(n.Addresses, ",")
+		masks := strings.Split(n.Subnet, ",")
+		for i, a := range addresses {
+			ip := net.ParseIP(a).To4()
+			if ip == nil || i >= len(masks) {
+				continue
+			}
+
+			mask := net.ParseIP(masks[i]).To4()
+			if mask == nil {
+				continue
+			}
+
+			ipNet := &net.IPNet{IP: ip.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)}
+			subnets = append(subnets, subnet{iface: n.Name, ip: ip, net: ipNet})
+		}
+	}
+
+	if len(subnets) == 0 {
+		return nil, errors.New("no active network adapters with an IPv4 subnet were found")
+	}
+
+	return subnets, nil
+}
+
+// getSubnetHosts returns every usable address but ours
+func getSubnetHosts(s subnet) []net.IP {
+	hosts := []net.IP{}
+
+	ones, bits := s.net.Mask.Size()
+	if bits-ones < 2 {
+		return hosts
+	}
+
+	first := binary.BigEndian.Uint32(s.net.IP.To4())
+	last := first | ^binary.BigEndian.Uint32(net.IP(s.net.Mask).To4())
+	for n := first + 1; n < last; n++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, n)
+		if ip.Equal(s.ip) {
+			continue
+		}
+		hosts = append(hosts, ip)
+	}
+
+	return hosts
+}
+
+func pingSweep(hosts []net.IP, limiter *time.Ticker, timeout time.Duration) (map[string]bool, error) {
+	alive := map[string]bool{}
+
+	c, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return alive, err
+	}
+	defer c.Close()
+
+	id := os.Getpid() & 0xffff
+
+	var mu sync.Mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1500)
+		for {
+			n, peer, err := c.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			msg, err := icmp.ParseMessage(ipv4.ICMPTypeEcho.Protocol(), buf[:n])
+			if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+
+			if echo, ok := msg.Body.(*icmp.Echo); ok && echo.ID == id {
+				mu.Lock()
+				alive[peer.String()] = true
+				mu.Unlock()
+			}
+		}
+	}()
+
+	for i, h := range hosts {
+		<-limiter.C
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Code: 0,
+			Body: &icmp.Echo{ID: id, Seq: i & 0xffff, Data: []byte("scnorion")},
+		}
+
+		data, err := msg.Marshal(nil)
+		if err != nil {
+			continue
+		}
+
+		// Unreachable hosts are expected so errors are ignored
+		_, _ = c.WriteTo(data, &net.IPAddr{IP: h})
+	}
+
+	// Wait for the last replies and stop the reader
+	if err := c.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return alive, err
+	}
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	return alive, nil
+}
+
+func tcpSweep(hosts []net.IP, ports []int, limiter *time.Ticker, timeout time.Duration) map[string][]int {
+	openPorts := map[string][]int{}
+
+	type probe struct {
+		ip   string
+		port int
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	probes := make(chan probe)
+
+	for range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range probes {
+				conn, err := net.DialTimeout("tcp4", net.JoinHostPort(p.ip, strconv.Itoa(p.port)), timeout)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+
+				mu.Lock()
+				openPorts[p.ip] = append(openPorts[p.ip], p.port)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, h := range hosts {
+		for _, port := range ports {
+			<-limiter.C
+			probes <- probe{ip: h.String(), port: port}
+		}
+	}
+	close(probes)
+	wg.Wait()
+
+	return openPorts
+}
+
+// readARPTable returns the complete entries for the interface from /proc/net/arp
+func readARPTable(iface string) (map[string]string, error) {
+	entries := map[string]string{}
+
+	f, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return entries, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // skip header
+	for scanner.Scan() {
+		// IP address  HW type  Flags  HW address  Mask  Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[5] != iface {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[2], 0, 32)
+		if err != nil || flags&0x2 == 0 || fields[3] == "00:00:00:00:00:00" {
+			continue
+		}
+
+		entries[fields[0]] = strings.ToUpper(fields[3])
+	}
+
+	return entries, scanner.Err()
+}
+
+func reverseLookup(ip string, timeout time.Duration) string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(names[0], ".")
+}
@@ -0,0 +1,81 @@
+package discovery
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OUI databases shipped by common packages (ieee-data, hwdata, nmap)
+var ouiFiles = []string{
+	"/usr/share/ieee-data/oui.txt",
+	"/var/lib/ieee-data/oui.txt",
+	"/usr/share/hwdata/oui.txt",
+	"/usr/share/misc/oui.txt",
+	"/usr/share/nmap/nmap-mac-prefixes",
+}
+
+var (
+	ouiOnce   sync.Once
+	ouiVendor map[string]string
+)
+
+func getVendor(mac string) string {
+	if mac == "" {
+		return ""
+	}
+
+	ouiOnce.Do(loadOUIDatabase)
+
+	prefix := strings.ToUpper(strings.NewReplacer(":", "", "-", "").Replace(mac))
+	if len(prefix) < 6 {
+		return ""
+	}
+
+	return ouiVendor[prefix[:6]]
+}
+
+func loadOUIDatabase() {
+	ouiVendor = map[string]string{}
+
+	for _, path := range ouiFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			prefix, vendor := parseOUILine(scanner.Text())
+			if prefix != "" {
+				if _, ok := ouiVendor[prefix]; !ok {
+					ouiVendor[prefix] = vendor
+				}
+			}
+		}
+		f.Close()
+	}
+}
+
+// parseOUILine understands the IEEE format "00-00-0C   (hex)		Cisco Systems, Inc"
+// and the nmap format "00000C Cisco Systems"
+func parseOUILine(line string) (string, string) {
+	if i := strings.Index(line, "(hex)"); i > 0 {
+		prefix := strings.ReplaceAll(strings.TrimSpace(line[:i]), "-", "")
+		if len(prefix) != 6 {
+			return "", ""
+		}
+		return strings.ToUpper(prefix), strings.TrimSpace(line[i+len("(hex)"):])
+	}
+
+	if strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+
+	prefix, vendor, found := strings.Cut(line, " ")
+	if !found || len(prefix) != 6 {
+		return "", ""
+	}
+	return strings.ToUpper(prefix), strings.TrimSpace(vendor)
+}
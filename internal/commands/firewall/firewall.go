@@ -0,0 +1,227 @@
+package firewall
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	BACKEND_UFW       = "ufw"
+	BACKEND_FIREWALLD = "firewalld"
+	BACKEND_NFTABLES  = "nftables"
+	BACKEND_IPTABLES  = "iptables"
+)
+
+const (
+	ACTION_ENABLE        = "enable"
+	ACTION_DISABLE       = "disable"
+	ACTION_OPEN_PORT     = "open_port"
+	ACTION_CLOSE_PORT    = "close_port"
+	ACTION_RESTRICT_PORT = "restrict_port"
+)
+
+// Actions of the rules and default policies, whatever the frontend calls them
+const (
+	RULE_ALLOW    = "allow"
+	RULE_DENY     = "deny"
+	RULE_REJECT   = "reject"
+	RULE_LIMIT    = "limit"
+	RULE_DISABLED = "disabled"
+)
+
+const (
+	DIRECTION_IN      = "in"
+	DIRECTION_OUT     = "out"
+	DIRECTION_FORWARD = "forward"
+)
+
+// Who can reach a port through the firewall
+const (
+	ACCESS_ANY        = "any"
+	ACCESS_RESTRICTED = "restricted"
+	ACCESS_BLOCKED    = "blocked"
+	ACCESS_UNKNOWN    = "unknown"
+)
+
+// SSH_PORT is allowed before enabling the firewall if the port of sshd can't be read
+const SSH_PORT = 22
+
+// Rules added by the agent carry this comment if the frontend supports comments
+const RULE_COMMENT = "scnorion-agent"
+
+type DefaultPolicies struct {
+	Incoming string `json:"incoming"`
+	Outgoing string `json:"outgoing"`
+	Routed   string `json:"routed,omitempty"`
+}
+
+type Rule struct {
+	Direction   string `json:"direction"`
+	Action      string `json:"action"`
+	Protocol    string `json:"protocol,omitempty"`
+	Port        string `json:"port,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Interface   string `json:"interface,omitempty"`
+	Managed     bool   `json:"managed"`
+	Raw         string `json:"raw"`
+	// ref is what the backend needs to delete the rule
+	ref []string
+	// partial is true if some matches of the rule couldn't be read e.g negations or jumps to other chains
+	partial bool
+}
+
+type Firewall struct {
+	Backend         string          `json:"backend"`
+	Enabled         bool            `json:"enabled"`
+	DefaultPolicies DefaultPolicies `json:"default_policies"`
+	Rules           []Rule          `json:"rules"`
+	// SFTPAccess tells who can reach the agent's SFTP server, ideally only the console network
+	SFTPAccess string `json:"sftp_access,omitempty"`
+}
+
+type FirewallRequest struct {
+	Action   string `json:"action"`
+	Port     int    `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	// Source is an IP address or a CIDR, restrict_port requires it e.g the console network,
+	// enable uses it to restrict the SSH and SFTP ports
+	Source string `json:"source,omitempty"`
+	// SFTPPort and VNCProxyPort are set by the agent, they're allowed before enabling the firewall
+	SFTPPort     int `json:"-"`
+	VNCProxyPort int `json:"-"`
+}
+
+type FirewallResult struct {
+	Firewall *Firewall `json:"firewall,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (req *FirewallRequest) validate() error {
+	if req.Source != "" && net.ParseIP(req.Source) == nil {
+		if _, _, err := net.ParseCIDR(req.Source); err != nil {
+			return fmt.Errorf("source %s is not a valid IP address or network", req.Source)
+		}
+	}
+
+	switch req.Action {
+	case ACTION_ENABLE, ACTION_DISABLE:
+		return nil
+	case ACTION_OPEN_PORT, ACTION_CLOSE_PORT, ACTION_RESTRICT_PORT:
+	default:
+		return fmt.Errorf("action %s is not valid", req.Action)
+	}
+
+	if req.Port < 1 || req.Port > 65535 {
+		return fmt.Errorf("port %d is not valid", req.Port)
+	}
+
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	if req.Protocol != "tcp" && req.Protocol != "udp" {
+		return fmt.Errorf("protocol %s is not valid", req.Protocol)
+	}
+
+	if req.Action == ACTION_RESTRICT_PORT && req.Source == "" {
+		return fmt.Errorf("a source network is required to restrict port %d", req.Port)
+	}
+	return nil
+}
+
+// isAnySource is true for rules that don't filter by source
+func isAnySource(source string) bool {
+	switch source {
+	case "", "any", "Anywhere", "0.0.0.0/0", "::/0":
+		return true
+	}
+	return false
+}
+
+func isIPv6(source string) bool {
+	return strings.Contains(source, ":")
+}
+
+// portMatches checks a single port, a list or a range e.g 22, 80,443, 6000:6010 or 6000-6010,
+// rules without port apply to all of them
+func (r *Rule) portMatches(port int) bool {
+	if r.Port == "" {
+		return true
+	}
+
+	for p := range strings.SplitSeq(r.Port, ",") {
+		from, to, isRange := strings.Cut(p, ":")
+		if !isRange {
+			from, to, isRange = strings.Cut(p, "-")
+		}
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				continue
+			}
+		}
+		if port >= start && port <= end {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) protocolMatches(protocol string) bool {
+	return r.Protocol == "" || r.Protocol == protocol
+}
+
+// exactPort is true for rules opening only that port, the ones we may remove
+func (r *Rule) exactPort(port int, protocol string) bool {
+	return r.Direction == DIRECTION_IN && r.Port == strconv.Itoa(port) && r.protocolMatches(protocol)
+}
+
+// PortAccess evaluates the incoming rules in order, as the frontends do, to find who can connect to the port.
+// We don't know which interface the connections come from nor evaluate partial rules so if one of them may
+// let the connections in the access is unknown, loopback rules are skipped
+func (f *Firewall) PortAccess(port int, protocol string) string {
+	if !f.Enabled {
+		return ACCESS_ANY
+	}
+
+	restricted := false
+	for _, r := range f.Rules {
+		if r.Direction != DIRECTION_IN || r.Interface == "lo" || !r.portMatches(port) || !r.protocolMatches(protocol) {
+			continue
+		}
+		if r.partial || r.Interface != "" {
+			if r.Action != RULE_DENY && r.Action != RULE_REJECT {
+				return ACCESS_UNKNOWN
+			}
+			continue
+		}
+		switch r.Action {
+		case RULE_ALLOW, RULE_LIMIT:
+			if isAnySource(r.Source) {
+				return ACCESS_ANY
+			}
+			restricted = true
+		case RULE_DENY, RULE_REJECT:
+			if isAnySource(r.Source) {
+				if restricted {
+					return ACCESS_RESTRICTED
+				}
+				return ACCESS_BLOCKED
+			}
+		}
+	}
+
+	if restricted {
+		return ACCESS_RESTRICTED
+	}
+	if f.DefaultPolicies.Incoming == RULE_ALLOW {
+		return ACCESS_ANY
+	}
+	return ACCESS_BLOCKED
+}
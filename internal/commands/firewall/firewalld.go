@@ -0,0 +1,235 @@
+//go:build linux
+
+package firewall
+
+import (
+	"fmt"
+	"strings"
+)
+
+type firewalldBackend struct{}
+
+func (b *firewalldBackend) name() string {
+	return BACKEND_FIREWALLD
+}
+
+func (b *firewalldBackend) active() bool {
+	out, err := run("firewall-cmd", "--state")
+	return err == nil && strings.TrimSpace(out) == "running"
+}
+
+func (b *firewalldBackend) defaultZone() (string, error) {
+	out, err := run("firewall-cmd", "--get-default-zone")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// status reads the default zone, it's the one where the agent adds its rules
+func (b *firewalldBackend) status(f *Firewall) error {
+	f.DefaultPolicies.Outgoing = RULE_ALLOW
+	f.Enabled = b.active()
+	if !f.Enabled {
+		return nil
+	}
+
+	zone, err := b.defaultZone()
+	if err != nil {
+		return err
+	}
+
+	out, err := run("firewall-cmd", "--zone="+zone, "--list-all")
+	if err != nil {
+		return err
+	}
+	parseZone(f, zone, out, b.servicePorts)
+	return nil
+}
+
+// parseZone reads the output of firewall-cmd --list-all, servicePorts resolves
+// the ports of a service e.g ssh is 22/tcp
+func parseZone(f *Firewall, zone, output string, servicePorts func(service string) []string) {
+	inRichRules := false
+	for line := range strings.SplitSeq(output, "\n") {
+		if inRichRules && strings.HasPrefix(line, "\t") {
+			if rules, ok := parseRichRule(strings.TrimSpace(line), servicePorts); ok {
+				for _, r := range rules {
+					r.ref = []string{zone, "rich-rule", strings.TrimSpace(line)}
+					f.Rules = append(f.Rules, r)
+				}
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		inRichRules = key == "rich rules"
+
+		switch key {
+		case "target":
+			switch value {
+			case "ACCEPT":
+				f.DefaultPolicies.Incoming = RULE_ALLOW
+			case "DROP":
+				f.DefaultPolicies.Incoming = RULE_DENY
+			default:
+				f.DefaultPolicies.Incoming = RULE_REJECT
+			}
+		case "forward":
+			f.DefaultPolicies.Routed = RULE_DENY
+			if value == "yes" {
+				f.DefaultPolicies.Routed = RULE_ALLOW
+			}
+		case "services":
+			for _, service := range strings.Fields(value) {
+				for _, port := range servicePorts(service) {
+					r := Rule{Direction: DIRECTION_IN, Action: RULE_ALLOW, Raw: "service " + service, ref: []string{zone, "service", service}}
+					r.Port, r.Protocol, _ = strings.Cut(port, "/")
+					f.Rules = append(f.Rules, r)
+				}
+			}
+		case "ports":
+			for _, port := range strings.Fields(value) {
+				r := Rule{Direction: DIRECTION_IN, Action: RULE_ALLOW, Raw: "port " + port, ref: []string{zone, "port", port}}
+				r.Port, r.Protocol, _ = strings.Cut(port, "/")
+				r.Port = strings.ReplaceAll(r.Port, "-", ":")
+				f.Rules = append(f.Rules, r)
+			}
+		}
+	}
+}
+
+func (b *firewalldBackend) servicePorts(service string) []string {
+	out, err := run("firewall-cmd", "--info-service="+service)
+	if err != nil {
+		return nil
+	}
+	for line := range strings.SplitSeq(out, "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "ports:"); found {
+			return strings.Fields(value)
+		}
+	}
+	return nil
+}
+
+// parseRichRule reads e.g rule family="ipv4" source address="10.0.0.0/8" port port="2022" protocol="tcp" accept,
+// a rule is returned for every port of its service. ICMP, masquerade and mark rules don't open ports, rules
+// with negations, ipsets or forwarded ports are partial
+func parseRichRule(rule string, servicePorts func(service string) []string) ([]Rule, bool) {
+	r := Rule{Direction: DIRECTION_IN, Raw: rule}
+
+	element, service := "", ""
+	for _, token := range splitQuoted(rule) {
+		attribute, value, isAttribute := strings.Cut(token, "=")
+		if !isAttribute {
+			element = token
+			switch element {
+			case "rule", "source", "destination", "service", "port", "protocol", "log", "audit", "limit":
+			case "accept":
+				r.Action = RULE_ALLOW
+			case "drop":
+				r.Action = RULE_DENY
+			case "reject":
+				r.Action = RULE_REJECT
+			case "icmp-block", "icmp-type", "masquerade", "mark":
+				return nil, false
+			default:
+				r.partial = true
+			}
+			continue
+		}
+
+		switch element + " " + attribute {
+		case "source address":
+			r.Source = value
+		case "destination address":
+			r.Destination = value
+		case "service name":
+			service = value
+		case "port port":
+			r.Port = strings.ReplaceAll(value, "-", ":")
+		case "port protocol", "protocol value":
+			r.Protocol = value
+		case "rule family", "rule priority", "log prefix", "log level", "limit value", "reject type":
+		default:
+			r.partial = true
+		}
+	}
+
+	// log or audit only rules
+	if r.Action == "" && !r.partial {
+		return nil, false
+	}
+
+	if service == "" {
+		return []Rule{r}, true
+	}
+	ports := servicePorts(service)
+	if len(ports) == 0 {
+		r.partial = true
+		return []Rule{r}, true
+	}
+	rules := []Rule{}
+	for _, port := range ports {
+		sr := r
+		sr.Port, sr.Protocol, _ = strings.Cut(port, "/")
+		sr.Port = strings.ReplaceAll(sr.Port, "-", ":")
+		rules = append(rules, sr)
+	}
+	return rules, true
+}
+
+func (b *firewalldBackend) setEnabled(enabled bool) error {
+	return setServiceEnabled("firewalld", enabled)
+}
+
+// change applies the change to the running firewall and to the permanent
+// configuration, some rules may exist only in one of them
+func (b *firewalldBackend) change(args ...string) error {
+	_, runtimeErr := run("firewall-cmd", args...)
+	_, permanentErr := run("firewall-cmd", append([]string{"--permanent"}, args...)...)
+	if runtimeErr != nil && permanentErr != nil {
+		return runtimeErr
+	}
+	return nil
+}
+
+func (b *firewalldBackend) add(rule Rule) error {
+	zone, err := b.defaultZone()
+	if err != nil {
+		return err
+	}
+
+	if rule.Source == "" && rule.Action == RULE_ALLOW {
+		return b.change("--zone="+zone, "--add-port="+rule.Port+"/"+rule.Protocol)
+	}
+
+	action := "accept"
+	if rule.Action == RULE_DENY {
+		action = "drop"
+	}
+
+	// The family is only needed with an address
+	richRule := fmt.Sprintf(`rule port port="%s" protocol="%s" %s`, rule.Port, rule.Protocol, action)
+	if rule.Source != "" {
+		family := "ipv4"
+		if isIPv6(rule.Source) {
+			family = "ipv6"
+		}
+		richRule = fmt.Sprintf(`rule family="%s" source address="%s" port port="%s" protocol="%s" %s`, family, rule.Source, rule.Port, rule.Protocol, action)
+	}
+	return b.change("--zone="+zone, "--add-rich-rule="+richRule)
+}
+
+func (b *firewalldBackend) remove(rules []Rule) error {
+	for _, r := range rules {
+		if err := b.change("--zone="+r.ref[0], "--remove-"+r.ref[1]+"="+r.ref[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
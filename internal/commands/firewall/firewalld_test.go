@@ -0,0 +1,147 @@
+//go:build linux
+
+package firewall
+
+import "testing"
+
+var firewalldServices = map[string][]string{
+	"cockpit":       {"9090/tcp"},
+	"dhcpv6-client": {"546/udp"},
+	"ssh":           {"22/tcp"},
+}
+
+func TestFirewalldPortAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		rules    int
+		expected map[int]string
+	}{
+		{
+			name: "rhel default",
+			output: `public (active)
+  target: default
+  icmp-block-inversion: no
+  interfaces: eth0
+  sources: 
+  services: cockpit dhcpv6-client ssh
+  ports: 
+  protocols: 
+  forward: yes
+  masquerade: no
+  forward-ports: 
+  source-ports: 
+  icmp-blocks: 
+  rich rules: 
+`,
+			rules:    3,
+			expected: map[int]string{22: ACCESS_ANY, 9090: ACCESS_ANY, 2022: ACCESS_BLOCKED},
+		},
+		{
+			name: "restricted by the agent",
+			output: `public (active)
+  target: default
+  icmp-block-inversion: no
+  interfaces: eth0
+  sources: 
+  services: dhcpv6-client ssh
+  ports: 8000-8010/tcp
+  protocols: 
+  forward: yes
+  masquerade: no
+  forward-ports: 
+  source-ports: 
+  icmp-blocks: 
+  rich rules: 
+	rule family="ipv4" source address="10.0.0.0/8" port port="2022" protocol="tcp" accept
+	rule family="ipv4" source address="192.168.1.0/24" service name="cockpit" accept
+	rule family="ipv4" source NOT address="10.0.0.0/8" port port="8080" protocol="tcp" accept
+	rule family="ipv4" source address="10.0.0.0/8" log prefix="sftp attempt" level="info" limit value="1/m"
+`,
+			rules:    6,
+			expected: map[int]string{22: ACCESS_ANY, 2022: ACCESS_RESTRICTED, 8005: ACCESS_ANY, 8080: ACCESS_UNKNOWN, 9090: ACCESS_RESTRICTED},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Firewall{Enabled: true}
+			parseZone(&f, "public", tt.output, func(service string) []string { return firewalldServices[service] })
+
+			if len(f.Rules) != tt.rules {
+				t.Errorf("expected %d rules, got %d: %+v", tt.rules, len(f.Rules), f.Rules)
+			}
+			for port, access := range tt.expected {
+				if got := f.PortAccess(port, "tcp"); got != access {
+					t.Errorf("expected %s access to port %d, got %s", access, port, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRichRule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		ok       bool
+		expected Rule
+	}{
+		{
+			rule:     `rule family="ipv6" source address="fd00::/8" port port="6000-6010" protocol="udp" drop`,
+			ok:       true,
+			expected: Rule{Source: "fd00::/8", Port: "6000:6010", Protocol: "udp", Action: RULE_DENY},
+		},
+		{
+			rule:     `rule protocol value="icmp" accept limit value="10/s"`,
+			ok:       true,
+			expected: Rule{Protocol: "icmp", Action: RULE_ALLOW},
+		},
+		{
+			rule:     `rule family="ipv4" source address="10.0.0.0/8" port port="22" protocol="tcp" reject type="icmp-admin-prohibited"`,
+			ok:       true,
+			expected: Rule{Source: "10.0.0.0/8", Port: "22", Protocol: "tcp", Action: RULE_REJECT},
+		},
+		{
+			rule:     `rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`,
+			ok:       true,
+			expected: Rule{Source: "10.0.0.0/8", Port: "22", Protocol: "tcp", Action: RULE_ALLOW},
+		},
+		{
+			rule:     `rule family="ipv4" source address="10.0.0.0/8" service name="unknown" accept`,
+			ok:       true,
+			expected: Rule{Source: "10.0.0.0/8", Action: RULE_ALLOW, partial: true},
+		},
+		{
+			rule:     `rule family="ipv4" source ipset="blocklist" drop`,
+			ok:       true,
+			expected: Rule{Action: RULE_DENY, partial: true},
+		},
+		{
+			rule:     `rule family="ipv4" forward-port port="80" protocol="tcp" to-port="8080"`,
+			ok:       true,
+			expected: Rule{partial: true},
+		},
+		{rule: `rule icmp-block name="echo-request"`},
+		{rule: `rule family="ipv4" source address="10.0.0.0/8" mark set="0x1"`},
+		{rule: `rule family="ipv4" source address="10.0.0.0/8" log prefix="sftp attempt" level="info"`},
+	}
+
+	for _, tt := range tests {
+		rules, ok := parseRichRule(tt.rule, func(service string) []string { return firewalldServices[service] })
+		if ok != tt.ok {
+			t.Errorf("expected %v parsing %s, got %v", tt.ok, tt.rule, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(rules) != 1 {
+			t.Errorf("expected 1 rule parsing %s, got %+v", tt.rule, rules)
+			continue
+		}
+		r := rules[0]
+		if r.Source != tt.expected.Source || r.Port != tt.expected.Port || r.Protocol != tt.expected.Protocol || r.Action != tt.expected.Action || r.partial != tt.expected.partial {
+			t.Errorf("unexpected rule %+v parsing %s", r, tt.rule)
+		}
+	}
+}
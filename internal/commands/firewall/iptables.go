@@ -0,0 +1,276 @@
+//go:build linux
+
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Files loaded by iptables-services for each command
+var iptablesServicesRules = map[string]string{
+	"iptables":  "/etc/sysconfig/iptables",
+	"ip6tables": "/etc/sysconfig/ip6tables",
+}
+
+var iptablesChainDirections = map[string]string{
+	"INPUT":   DIRECTION_IN,
+	"OUTPUT":  DIRECTION_OUT,
+	"FORWARD": DIRECTION_FORWARD,
+}
+
+var iptablesPolicies = map[string]string{
+	"ACCEPT": RULE_ALLOW,
+	"DROP":   RULE_DENY,
+	"REJECT": RULE_REJECT,
+}
+
+type iptablesBackend struct{}
+
+func (b *iptablesBackend) name() string {
+	return BACKEND_IPTABLES
+}
+
+// legacy is true if iptables doesn't use nftables under the hood, otherwise
+// the rules are read from the nftables ruleset
+func (b *iptablesBackend) legacy() bool {
+	out, err := run("iptables", "--version")
+	return err == nil && strings.Contains(out, "legacy")
+}
+
+func (b *iptablesBackend) active() bool {
+	f := Firewall{}
+	if err := b.status(&f); err != nil {
+		return false
+	}
+	return f.Enabled
+}
+
+func (b *iptablesBackend) status(f *Firewall) error {
+	out, err := run("iptables", "-S")
+	if err != nil {
+		return err
+	}
+	f.DefaultPolicies = DefaultPolicies{Incoming: RULE_ALLOW, Outgoing: RULE_ALLOW, Routed: RULE_ALLOW}
+	b.parse(f, "iptables", out)
+
+	// IPv6 may be disabled
+	if _, err := exec.LookPath("ip6tables"); err == nil {
+		if out, err := run("ip6tables", "-S"); err == nil {
+			b.parse(f, "ip6tables", out)
+		}
+	}
+	return nil
+}
+
+// parse reads the output of iptables -S, rules in user chains are not reported
+func (b *iptablesBackend) parse(f *Firewall, command, output string) {
+	for line := range strings.SplitSeq(output, "\n") {
+		args := splitQuoted(strings.TrimSpace(line))
+		if len(args) < 3 {
+			continue
+		}
+		direction, ok := iptablesChainDirections[args[1]]
+		if !ok {
+			continue
+		}
+
+		switch args[0] {
+		case "-P":
+			policy := iptablesPolicies[args[2]]
+			if policy != RULE_ALLOW {
+				f.Enabled = true
+				switch direction {
+				case DIRECTION_IN:
+					f.DefaultPolicies.Incoming = policy
+				case DIRECTION_OUT:
+					f.DefaultPolicies.Outgoing = policy
+				case DIRECTION_FORWARD:
+					f.DefaultPolicies.Routed = policy
+				}
+			}
+		case "-A":
+			f.Enabled = true
+			if r, ok := parseIptablesRule(args[2:]); ok {
+				r.Direction = direction
+				r.Raw = strings.TrimSpace(line)
+				r.ref = append([]string{command, args[1]}, args[2:]...)
+				f.Rules = append(f.Rules, r)
+			}
+		}
+	}
+}
+
+// parseIptablesRule returns false for rules that can't open a port e.g about established connections or
+// logging. Rules jumping to user chains, with negations or matches that can't be evaluated are partial
+func parseIptablesRule(args []string) (Rule, bool) {
+	r := Rule{}
+
+	// Every option is followed by its value
+	for i := 0; i < len(args); i += 2 {
+		value := ""
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+
+		switch args[i] {
+		case "-s":
+			r.Source = value
+		case "-d":
+			r.Destination = value
+		case "-i", "-o":
+			r.Interface = value
+		case "-p":
+			r.Protocol = value
+		case "-m":
+			switch value {
+			case "tcp", "udp", "multiport", "comment", "state", "conntrack":
+			default:
+				return partialIptablesRule(args)
+			}
+		case "--dport", "--dports":
+			r.Port = value
+		case "--comment":
+			r.Managed = value == RULE_COMMENT
+		case "--state", "--ctstate":
+			// Rules about established connections don't open ports
+			if !slices.Contains(strings.Split(value, ","), "NEW") {
+				return r, false
+			}
+		case "--reject-with":
+		case "-j":
+			switch value {
+			case "ACCEPT":
+				r.Action = RULE_ALLOW
+			case "DROP":
+				r.Action = RULE_DENY
+			case "REJECT":
+				r.Action = RULE_REJECT
+			case "LOG", "RETURN":
+				return r, false
+			default:
+				return partialIptablesRule(args)
+			}
+		default:
+			return partialIptablesRule(args)
+		}
+	}
+
+	return r, r.Action != ""
+}
+
+// partialIptablesRule reads the protocol, the port and the target of a rule that can't be evaluated,
+// options can have no value here e.g --syn so each argument is checked. Negated options are skipped
+func partialIptablesRule(args []string) (Rule, bool) {
+	r := Rule{partial: true}
+
+	for i := 0; i+1 < len(args); i++ {
+		if i > 0 && args[i-1] == "!" {
+			continue
+		}
+
+		value := args[i+1]
+		switch args[i] {
+		case "-i":
+			r.Interface = value
+		case "-p":
+			r.Protocol = value
+		case "--dport", "--dports":
+			r.Port = value
+		case "--state", "--ctstate":
+			if !slices.Contains(strings.Split(value, ","), "NEW") {
+				return r, false
+			}
+		case "-j":
+			switch value {
+			case "ACCEPT":
+				r.Action = RULE_ALLOW
+			case "DROP":
+				r.Action = RULE_DENY
+			case "REJECT":
+				r.Action = RULE_REJECT
+			case "LOG", "RETURN":
+				return r, false
+			}
+		}
+	}
+	return r, true
+}
+
+func (b *iptablesBackend) setEnabled(enabled bool) error {
+	return errors.New("iptables rules are not managed by a service, the firewall can't be enabled or disabled")
+}
+
+// add inserts allow rules at the top of the chain and appends the deny ones,
+// rules without source are added for IPv4 and IPv6
+func (b *iptablesBackend) add(rule Rule) error {
+	commands := []string{"iptables"}
+	switch {
+	case rule.Source != "" && isIPv6(rule.Source):
+		commands = []string{"ip6tables"}
+	case rule.Source == "":
+		if _, err := exec.LookPath("ip6tables"); err == nil {
+			commands = append(commands, "ip6tables")
+		}
+	}
+
+	position, target := []string{"-A", "INPUT"}, "DROP"
+	if rule.Action == RULE_ALLOW {
+		position, target = []string{"-I", "INPUT", "1"}, "ACCEPT"
+	}
+
+	for _, command := range commands {
+		args := append([]string{}, position...)
+		if rule.Source != "" {
+			args = append(args, "-s", rule.Source)
+		}
+		args = append(args, "-p", rule.Protocol, "--dport", rule.Port, "-m", "comment", "--comment", RULE_COMMENT, "-j", target)
+		if _, err := run(command, args...); err != nil {
+			return err
+		}
+	}
+	return b.save()
+}
+
+// remove deletes the rules using the same specification iptables -S printed
+func (b *iptablesBackend) remove(rules []Rule) error {
+	for _, r := range rules {
+		args := append([]string{"-D"}, r.ref[1:]...)
+		if _, err := run(r.ref[0], args...); err != nil {
+			return err
+		}
+	}
+	return b.save()
+}
+
+// save keeps the rules after a reboot with netfilter-persistent on Debian based distributions
+// or with the files loaded by iptables-services on Red Hat based ones
+func (b *iptablesBackend) save() error {
+	if _, err := exec.LookPath("netfilter-persistent"); err == nil {
+		_, err := run("netfilter-persistent", "save")
+		return err
+	}
+
+	if _, err := os.Stat(iptablesServicesRules["iptables"]); err != nil {
+		return errors.New("the rules have been changed until the next reboot, netfilter-persistent or iptables-services are required to keep them")
+	}
+
+	for command, path := range iptablesServicesRules {
+		if _, err := exec.LookPath(command); err != nil {
+			continue
+		}
+		// Warnings are printed to stderr, only the rules are saved
+		out, err := exec.Command(command + "-save").Output()
+		if err != nil {
+			return fmt.Errorf("%s-save failed, reason: %v", command, err)
+		}
+		if err := os.WriteFile(path, out, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package firewall
+
+import "testing"
+
+func TestIptablesPortAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		rules    int
+		expected map[int]string
+	}{
+		{
+			name:     "debian default",
+			output:   "-P INPUT ACCEPT\n-P FORWARD ACCEPT\n-P OUTPUT ACCEPT\n",
+			rules:    0,
+			expected: map[int]string{22: ACCESS_ANY, 2022: ACCESS_ANY},
+		},
+		{
+			name: "rhel iptables-services",
+			output: `-P INPUT ACCEPT
+-P FORWARD ACCEPT
+-P OUTPUT ACCEPT
+-A INPUT -m state --state RELATED,ESTABLISHED -j ACCEPT
+-A INPUT -p icmp -j ACCEPT
+-A INPUT -i lo -j ACCEPT
+-A INPUT -p tcp -m state --state NEW -m tcp --dport 22 -j ACCEPT
+-A INPUT -j REJECT --reject-with icmp-host-prohibited
+-A FORWARD -j REJECT --reject-with icmp-host-prohibited
+`,
+			rules:    5,
+			expected: map[int]string{22: ACCESS_ANY, 2022: ACCESS_BLOCKED},
+		},
+		{
+			name: "restricted by the agent",
+			output: `-P INPUT ACCEPT
+-P FORWARD ACCEPT
+-P OUTPUT ACCEPT
+-A INPUT ! -s 192.168.1.0/24 -p tcp -m tcp --dport 8080 -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 3389 -m recent --set -j ACCEPT
+-A INPUT -s 10.0.0.0/8 -p tcp -m tcp --dport 2022 -m comment --comment "scnorion-agent" -j ACCEPT
+-A INPUT -p tcp -m tcp --dport 2022 -j DROP
+-A INPUT -j ufw-before-input
+`,
+			rules:    5,
+			expected: map[int]string{2022: ACCESS_RESTRICTED, 8080: ACCESS_UNKNOWN, 3389: ACCESS_UNKNOWN},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Firewall{DefaultPolicies: DefaultPolicies{Incoming: RULE_ALLOW, Outgoing: RULE_ALLOW, Routed: RULE_ALLOW}}
+			b := iptablesBackend{}
+			b.parse(&f, "iptables", tt.output)
+
+			if len(f.Rules) != tt.rules {
+				t.Errorf("expected %d rules, got %d: %+v", tt.rules, len(f.Rules), f.Rules)
+			}
+			for port, access := range tt.expected {
+				if got := f.PortAccess(port, "tcp"); got != access {
+					t.Errorf("expected %s access to port %d, got %s", access, port, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIptablesRuleManaged(t *testing.T) {
+	r, ok := parseIptablesRule(splitQuoted(`-s 10.0.0.0/8 -p tcp -m tcp --dport 2022 -m comment --comment "scnorion-agent" -j ACCEPT`))
+	if !ok {
+		t.Fatal("expected the rule to be parsed")
+	}
+	if !r.Managed || r.Source != "10.0.0.0/8" || r.Port != "2022" || r.Protocol != "tcp" || r.Action != RULE_ALLOW {
+		t.Errorf("unexpected rule %+v", r)
+	}
+}
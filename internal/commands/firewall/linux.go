@@ -0,0 +1,293 @@
+//go:build linux
+
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const SSHD_CONFIG = "/etc/ssh/sshd_config"
+
+// backend is implemented by each frontend, rules are always incoming
+type backend interface {
+	name() string
+	status(f *Firewall) error
+	setEnabled(enabled bool) error
+	add(rule Rule) error
+	remove(rules []Rule) error
+}
+
+// Get returns the state and the rules of the detected firewall
+func Get() (*Firewall, error) {
+	b, err := detect()
+	if err != nil {
+		return nil, err
+	}
+
+	f := Firewall{Backend: b.name(), Rules: []Rule{}}
+	if err := b.status(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Control runs the action with the detected frontend and returns the firewall state afterwards
+func Control(req FirewallRequest) (*Firewall, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	b, err := detect()
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Action {
+	case ACTION_ENABLE:
+		err = enable(b, req)
+	case ACTION_DISABLE:
+		err = b.setEnabled(false)
+	case ACTION_OPEN_PORT:
+		err = b.add(Rule{Direction: DIRECTION_IN, Action: RULE_ALLOW, Protocol: req.Protocol, Port: strconv.Itoa(req.Port), Source: req.Source})
+	case ACTION_CLOSE_PORT:
+		err = closePort(b, req, func(r Rule) bool { return req.Source == "" || r.Source == req.Source })
+	case ACTION_RESTRICT_PORT:
+		// The source is allowed first so the port is never closed for the console
+		err = b.add(Rule{Direction: DIRECTION_IN, Action: RULE_ALLOW, Protocol: req.Protocol, Port: strconv.Itoa(req.Port), Source: req.Source})
+		if err == nil {
+			req.Source = ""
+			err = closePort(b, req, func(r Rule) bool { return isAnySource(r.Source) })
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Get()
+}
+
+// enable allows SSH, SFTP and the VNC proxy before enabling the firewall so the machine can still be reached.
+// Frontends that can't change rules while stopped, or whose service loads a configuration that flushes
+// them e.g nftables, get them once started. The firewall is disabled again if they can't be added
+func enable(b backend, req FirewallRequest) error {
+	// iptables can't be enabled, there's nothing to allow
+	if b.name() == BACKEND_IPTABLES {
+		return b.setEnabled(true)
+	}
+
+	if err := allowRemoteAccess(b, req, remoteAccessPorts(req)); err != nil {
+		log.Printf("[INFO]: remote access will be allowed once the firewall is enabled, reason: %v", err)
+	}
+	if err := b.setEnabled(true); err != nil {
+		return err
+	}
+
+	missing, err := missingRemoteAccess(b, req)
+	if err == nil && len(missing) > 0 {
+		err = allowRemoteAccess(b, req, missing)
+	}
+	if err != nil {
+		if disableErr := b.setEnabled(false); disableErr != nil {
+			log.Printf("[ERROR]: could not disable the firewall again, reason: %v", disableErr)
+		}
+		return fmt.Errorf("the firewall has not been enabled as SSH, SFTP and the VNC proxy could not be allowed, reason: %v", err)
+	}
+	return nil
+}
+
+// remoteAccessPorts are the TCP ports that must stay reachable to manage the machine
+func remoteAccessPorts(req FirewallRequest) []int {
+	ports := sshPorts()
+	for _, port := range []int{req.SFTPPort, req.VNCProxyPort} {
+		if port > 0 && !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// sshPorts prefers the effective configuration printed by sshd -T, the main
+// configuration file is read if sshd can't be run
+func sshPorts() []int {
+	path, err := exec.LookPath("sshd")
+	if err != nil {
+		path = "/usr/sbin/sshd"
+	}
+	out, err := exec.Command(path, "-T").Output()
+	if err != nil {
+		data, _ := os.ReadFile(SSHD_CONFIG)
+		out = data
+	}
+
+	ports := parseSshdPorts(string(out))
+	if len(ports) == 0 {
+		return []int{SSH_PORT}
+	}
+	return ports
+}
+
+// parseSshdPorts reads the port lines of sshd -T or sshd_config, sshd can listen on several ports
+func parseSshdPorts(config string) []int {
+	ports := []int{}
+	for line := range strings.SplitSeq(config, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "port") {
+			continue
+		}
+		if port, err := strconv.Atoi(fields[1]); err == nil && !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// allowRemoteAccess adds the allow rules for the ports, restricted to the source if any
+func allowRemoteAccess(b backend, req FirewallRequest, ports []int) error {
+	for _, port := range ports {
+		if err := b.add(Rule{Direction: DIRECTION_IN, Action: RULE_ALLOW, Protocol: "tcp", Port: strconv.Itoa(port), Source: req.Source}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingRemoteAccess reads the running firewall and returns the remote access ports that have no allow rule
+func missingRemoteAccess(b backend, req FirewallRequest) ([]int, error) {
+	f := Firewall{}
+	if err := b.status(&f); err != nil {
+		return nil, err
+	}
+
+	missing := []int{}
+	for _, port := range remoteAccessPorts(req) {
+		allowed := slices.ContainsFunc(f.Rules, func(r Rule) bool {
+			return (r.Action == RULE_ALLOW || r.Action == RULE_LIMIT) && r.Interface == "" && !r.partial &&
+				r.exactPort(port, "tcp") && (r.Source == req.Source || isAnySource(r.Source))
+		})
+		if !allowed {
+			missing = append(missing, port)
+		}
+	}
+	return missing, nil
+}
+
+// closePort removes the rules allowing the port and, if the default policy
+// accepts incoming connections, adds a rule to block it
+func closePort(b backend, req FirewallRequest, match func(r Rule) bool) error {
+	f := Firewall{}
+	if err := b.status(&f); err != nil {
+		return err
+	}
+
+	allows := []Rule{}
+	for _, r := range f.Rules {
+		if r.Action != RULE_ALLOW && r.Action != RULE_LIMIT {
+			continue
+		}
+		if !r.partial && r.exactPort(req.Port, req.Protocol) && match(r) && len(r.ref) > 0 {
+			allows = append(allows, r)
+		}
+	}
+	if len(allows) > 0 {
+		if err := b.remove(allows); err != nil {
+			return err
+		}
+	}
+
+	if f.DefaultPolicies.Incoming == RULE_ALLOW {
+		return b.add(Rule{Direction: DIRECTION_IN, Action: RULE_DENY, Protocol: req.Protocol, Port: strconv.Itoa(req.Port), Source: req.Source})
+	}
+	return nil
+}
+
+// detect prefers an active frontend, then the rules loaded in the kernel
+// and finally a frontend that is installed but not enabled
+func detect() (backend, error) {
+	ufw := &ufwBackend{}
+	firewalld := &firewalldBackend{}
+	nft := &nftablesBackend{}
+	iptables := &iptablesBackend{}
+
+	_, ufwErr := exec.LookPath("ufw")
+	_, firewalldErr := exec.LookPath("firewall-cmd")
+	_, nftErr := exec.LookPath("nft")
+	_, iptablesErr := exec.LookPath("iptables")
+
+	if ufwErr == nil && ufw.active() {
+		return ufw, nil
+	}
+	if firewalldErr == nil && firewalld.active() {
+		return firewalld, nil
+	}
+	if iptablesErr == nil && iptables.legacy() && iptables.active() {
+		return iptables, nil
+	}
+	if nftErr == nil && nft.active() {
+		return nft, nil
+	}
+
+	switch {
+	case ufwErr == nil:
+		return ufw, nil
+	case firewalldErr == nil:
+		return firewalld, nil
+	case nftErr == nil:
+		return nft, nil
+	case iptablesErr == nil:
+		return iptables, nil
+	}
+	return nil, errors.New("no firewall has been found")
+}
+
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("%s %s failed, reason: %v, output: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// setServiceEnabled starts and enables the unit of the frontend or stops and disables it
+func setServiceEnabled(unit string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	_, err := run("systemctl", action, "--now", unit)
+	return err
+}
+
+// splitQuoted splits a command line keeping quoted arguments together
+func splitQuoted(s string) []string {
+	args := []string{}
+	current := strings.Builder{}
+	inQuotes, hasArg := false, false
+
+	for _, c := range s {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case c == ' ' && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(c)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
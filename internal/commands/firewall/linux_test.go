@@ -0,0 +1,101 @@
+//go:build linux
+
+package firewall
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// fakeBackend can only add rules while it's enabled if stoppedRules is false,
+// flushOnStart drops them when it's enabled as the nftables service does
+type fakeBackend struct {
+	enabled      bool
+	stoppedRules bool
+	flushOnStart bool
+	failAdd      bool
+	rules        []Rule
+}
+
+func (b *fakeBackend) name() string { return "fake" }
+
+func (b *fakeBackend) status(f *Firewall) error {
+	f.Enabled = b.enabled
+	f.Rules = b.rules
+	return nil
+}
+
+func (b *fakeBackend) setEnabled(enabled bool) error {
+	if enabled && b.flushOnStart {
+		b.rules = nil
+	}
+	b.enabled = enabled
+	return nil
+}
+
+func (b *fakeBackend) add(rule Rule) error {
+	if b.failAdd || (!b.enabled && !b.stoppedRules) {
+		return errors.New("rules can't be added")
+	}
+	b.rules = append(b.rules, rule)
+	return nil
+}
+
+func (b *fakeBackend) remove(rules []Rule) error {
+	return nil
+}
+
+func TestEnable(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend fakeBackend
+		enabled bool
+		err     bool
+	}{
+		{name: "rules added while stopped", backend: fakeBackend{stoppedRules: true}, enabled: true},
+		{name: "rules added once started", backend: fakeBackend{}, enabled: true},
+		{name: "rules flushed when started", backend: fakeBackend{stoppedRules: true, flushOnStart: true}, enabled: true},
+		{name: "rules can't be added", backend: fakeBackend{failAdd: true}, enabled: false, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.backend
+			req := FirewallRequest{Action: ACTION_ENABLE, Source: "10.0.0.0/8", SFTPPort: 2022, VNCProxyPort: 1443}
+			err := enable(&b, req)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.enabled != tt.enabled {
+				t.Errorf("expected the firewall enabled to be %v", tt.enabled)
+			}
+			if tt.err {
+				return
+			}
+
+			ports := []string{}
+			for _, r := range b.rules {
+				if r.Action != RULE_ALLOW || r.Source != "10.0.0.0/8" {
+					t.Errorf("unexpected rule %+v", r)
+				}
+				ports = append(ports, r.Port)
+			}
+			expected := []string{}
+			for _, port := range remoteAccessPorts(req) {
+				expected = append(expected, strconv.Itoa(port))
+			}
+			if !slices.Equal(ports, expected) || !slices.Contains(ports, "2022") || !slices.Contains(ports, "1443") {
+				t.Errorf("expected SSH, SFTP and VNC proxy ports %v to be allowed, got %v", expected, ports)
+			}
+		})
+	}
+}
+
+func TestParseSshdPorts(t *testing.T) {
+	config := "# Port 2200\nPort 22\nport 2222\nListenAddress 0.0.0.0\n"
+	if ports := parseSshdPorts(config); !slices.Equal(ports, []int{22, 2222}) {
+		t.Errorf("expected ports 22 and 2222, got %v", ports)
+	}
+}
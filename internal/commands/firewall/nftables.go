@@ -0,0 +1,532 @@
+//go:build linux
+
+package firewall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Configurations loaded by the nftables service, Red Hat based distributions use the second one
+var nftablesConfigs = []string{"/etc/nftables.conf", "/etc/sysconfig/nftables.conf"}
+
+// Rules added by the agent, the file is included by the configuration of the service
+const NFTABLES_AGENT_RULES = "/etc/nftables.d/scnorion-agent.nft"
+
+// e.g table inet filter { or table filter, comments are skipped
+var nftTableRegexp = regexp.MustCompile(`(?m)^\s*table\s+(?:(ip|ip6|inet|arp|bridge|netdev)\s+)?([\w-]+)`)
+
+// e.g include "/etc/nftables/*.nft"
+var nftIncludeRegexp = regexp.MustCompile(`(?m)^\s*include\s+"([^"]+)"`)
+
+type nftRuleset struct {
+	Nftables []map[string]json.RawMessage `json:"nftables"`
+}
+
+type nftChain struct {
+	Family string `json:"family"`
+	Table  string `json:"table"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Hook   string `json:"hook"`
+	Policy string `json:"policy"`
+}
+
+type nftRule struct {
+	Family  string                       `json:"family"`
+	Table   string                       `json:"table"`
+	Chain   string                       `json:"chain"`
+	Handle  int                          `json:"handle"`
+	Comment string                       `json:"comment"`
+	Expr    []map[string]json.RawMessage `json:"expr"`
+}
+
+type nftMatch struct {
+	Op    string         `json:"op"`
+	Left  map[string]any `json:"left"`
+	Right any            `json:"right"`
+}
+
+var nftHookDirections = map[string]string{
+	"input":   DIRECTION_IN,
+	"output":  DIRECTION_OUT,
+	"forward": DIRECTION_FORWARD,
+}
+
+type nftablesBackend struct{}
+
+func (b *nftablesBackend) name() string {
+	return BACKEND_NFTABLES
+}
+
+func (b *nftablesBackend) ruleset() ([]nftChain, []nftRule, error) {
+	out, err := run("nft", "--json", "list", "ruleset")
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseNftRuleset(out)
+}
+
+// parseNftRuleset reads the output of nft --json list ruleset, only filter chains are kept
+func parseNftRuleset(out string) ([]nftChain, []nftRule, error) {
+	ruleset := nftRuleset{}
+	if err := json.Unmarshal([]byte(out), &ruleset); err != nil {
+		return nil, nil, fmt.Errorf("could not parse the nftables ruleset, reason: %v", err)
+	}
+
+	chains := []nftChain{}
+	rules := []nftRule{}
+	for _, object := range ruleset.Nftables {
+		if data, ok := object["chain"]; ok {
+			c := nftChain{}
+			if err := json.Unmarshal(data, &c); err == nil && c.Type == "filter" {
+				chains = append(chains, c)
+			}
+		}
+		if data, ok := object["rule"]; ok {
+			r := nftRule{}
+			if err := json.Unmarshal(data, &r); err == nil {
+				rules = append(rules, r)
+			}
+		}
+	}
+	return chains, rules, nil
+}
+
+// active is true if any filter chain is attached to a hook
+func (b *nftablesBackend) active() bool {
+	chains, _, err := b.ruleset()
+	if err != nil {
+		return false
+	}
+	for _, c := range chains {
+		if c.Hook != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *nftablesBackend) status(f *Firewall) error {
+	chains, rules, err := b.ruleset()
+	if err != nil {
+		return err
+	}
+	readNftRuleset(f, chains, rules)
+	return nil
+}
+
+// readNftRuleset fills the firewall with the base chains and their rules
+func readNftRuleset(f *Firewall, chains []nftChain, rules []nftRule) {
+	f.DefaultPolicies = DefaultPolicies{Incoming: RULE_ALLOW, Outgoing: RULE_ALLOW, Routed: RULE_ALLOW}
+	directions := map[string]string{}
+	for _, c := range chains {
+		direction, ok := nftHookDirections[c.Hook]
+		if !ok {
+			continue
+		}
+		f.Enabled = true
+		directions[c.Family+" "+c.Table+" "+c.Name] = direction
+
+		// Packets go through every base chain, a single drop policy is enough to block them
+		if c.Policy == "drop" {
+			switch direction {
+			case DIRECTION_IN:
+				f.DefaultPolicies.Incoming = RULE_DENY
+			case DIRECTION_OUT:
+				f.DefaultPolicies.Outgoing = RULE_DENY
+			case DIRECTION_FORWARD:
+				f.DefaultPolicies.Routed = RULE_DENY
+			}
+		}
+	}
+
+	for _, nr := range rules {
+		direction, ok := directions[nr.Family+" "+nr.Table+" "+nr.Chain]
+		if !ok {
+			continue
+		}
+		r, ok := parseNftRule(nr)
+		if !ok {
+			continue
+		}
+		r.Direction = direction
+		f.Rules = append(f.Rules, r)
+	}
+}
+
+// parseNftRule returns false for rules without verdict or that can't open a port e.g about established
+// connections. Rules jumping to other chains, with negations or matches that can't be evaluated are partial
+func parseNftRule(nr nftRule) (Rule, bool) {
+	r := Rule{
+		Managed: nr.Comment == RULE_COMMENT,
+		Raw:     fmt.Sprintf("%s %s %s handle %d", nr.Family, nr.Table, nr.Chain, nr.Handle),
+		ref:     []string{nr.Family, nr.Table, nr.Chain, strconv.Itoa(nr.Handle)},
+	}
+
+	limited, verdict := false, false
+	for _, expr := range nr.Expr {
+		for key, data := range expr {
+			switch key {
+			case "accept":
+				r.Action, verdict = RULE_ALLOW, true
+			case "drop":
+				r.Action, verdict = RULE_DENY, true
+			case "reject":
+				r.Action, verdict = RULE_REJECT, true
+			case "jump", "goto":
+				r.partial, verdict = true, true
+			case "counter", "log":
+			case "limit":
+				limited = true
+			case "match":
+				m := nftMatch{}
+				if err := json.Unmarshal(data, &m); err != nil {
+					r.partial = true
+					continue
+				}
+				if nftEstablishedMatch(m) {
+					return r, false
+				}
+				if !parseNftMatch(&r, m) {
+					r.partial = true
+				}
+			default:
+				r.partial = true
+			}
+		}
+	}
+
+	// Only some packets get the verdict of a rate limited rule
+	if limited {
+		if r.Action != RULE_ALLOW {
+			return r, false
+		}
+		r.Action = RULE_LIMIT
+	}
+	return r, verdict
+}
+
+// nftEstablishedMatch is true for matches about established connections, they don't open ports
+func nftEstablishedMatch(m nftMatch) bool {
+	ct, ok := m.Left["ct"].(map[string]any)
+	if !ok || ct["key"] != "state" || (m.Op != "==" && m.Op != "in") {
+		return false
+	}
+	return !slices.Contains(strings.Split(nftValue(m.Right), ","), "new")
+}
+
+// parseNftMatch returns false for negations and matches that can't be expressed with our rules
+func parseNftMatch(r *Rule, m nftMatch) bool {
+	if m.Op != "==" && m.Op != "in" {
+		return false
+	}
+	value := nftValue(m.Right)
+
+	if payload, ok := m.Left["payload"].(map[string]any); ok {
+		switch payload["field"] {
+		case "dport":
+			// th dport matches any transport protocol
+			if protocol, _ := payload["protocol"].(string); protocol != "th" {
+				r.Protocol = protocol
+			}
+			r.Port = value
+		case "saddr":
+			r.Source = value
+		case "daddr":
+			r.Destination = value
+		case "protocol", "nexthdr":
+			r.Protocol = value
+		default:
+			return false
+		}
+		return true
+	}
+
+	if meta, ok := m.Left["meta"].(map[string]any); ok {
+		switch meta["key"] {
+		case "iif", "oif", "iifname", "oifname":
+			r.Interface = value
+		case "l4proto":
+			r.Protocol = value
+		case "nfproto":
+		default:
+			return false
+		}
+		return true
+	}
+
+	// ct state new
+	if ct, ok := m.Left["ct"].(map[string]any); ok && ct["key"] == "state" {
+		return true
+	}
+	return false
+}
+
+// nftValue converts the right side of a match e.g 22, {"set": [80, 443]}, ["established", "related"],
+// {"range": [6000, 6010]} or {"prefix": {"addr": "10.0.0.0", "len": 8}}
+func nftValue(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.Itoa(int(value))
+	case []any:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, nftValue(item))
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		if set, ok := value["set"].([]any); ok {
+			items := []string{}
+			for _, item := range set {
+				items = append(items, nftValue(item))
+			}
+			return strings.Join(items, ",")
+		}
+		if r, ok := value["range"].([]any); ok && len(r) == 2 {
+			return nftValue(r[0]) + ":" + nftValue(r[1])
+		}
+		if prefix, ok := value["prefix"].(map[string]any); ok {
+			return nftValue(prefix["addr"]) + "/" + nftValue(prefix["len"])
+		}
+	}
+	return ""
+}
+
+// inputChain returns the chain where the agent adds its rules, inet tables are preferred as they handle IPv4 and IPv6
+func (b *nftablesBackend) inputChain(source string) (*nftChain, error) {
+	chains, _, err := b.ruleset()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *nftChain
+	for i, c := range chains {
+		if c.Hook != "input" {
+			continue
+		}
+		switch {
+		case c.Family == "inet":
+			return &chains[i], nil
+		case found == nil && c.Family == "ip" && !isIPv6(source):
+			found = &chains[i]
+		case found == nil && c.Family == "ip6" && source != "" && isIPv6(source):
+			found = &chains[i]
+		}
+	}
+	if found == nil {
+		return nil, errors.New("no nftables input chain has been found")
+	}
+	return found, nil
+}
+
+// setEnabled loads the configuration of the nftables service. Stopping the service flushes the whole
+// ruleset, docker or libvirt rules included, so only the tables of the configuration are deleted
+func (b *nftablesBackend) setEnabled(enabled bool) error {
+	if enabled {
+		if _, err := run("systemctl", "enable", "nftables"); err != nil {
+			return err
+		}
+		// The service is still active if it was disabled by deleting its tables
+		action := "start"
+		if exec.Command("systemctl", "is-active", "--quiet", "nftables").Run() == nil {
+			action = "reload"
+		}
+		_, err := run("systemctl", action, "nftables")
+		return err
+	}
+
+	tables := nftConfigTables()
+	if len(tables) == 0 {
+		return errors.New("no tables have been found in the nftables configuration, the ruleset has been left as it is")
+	}
+	if _, err := run("systemctl", "disable", "nftables"); err != nil {
+		return err
+	}
+
+	out, err := run("nft", "list", "tables")
+	if err != nil {
+		return err
+	}
+	for _, m := range nftTableRegexp.FindAllStringSubmatch(out, -1) {
+		family, name := nftTableFamily(m[1]), m[2]
+		if !slices.Contains(tables, family+" "+name) {
+			continue
+		}
+		if _, err := run("nft", "delete", "table", family, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nftConfig returns the configuration loaded by the service, if any
+func nftConfig() string {
+	for _, config := range nftablesConfigs {
+		if _, err := os.Stat(config); err == nil {
+			return config
+		}
+	}
+	return ""
+}
+
+// nftConfigTables returns the family and the name of the tables defined in the configuration
+// loaded by the service and the files it includes
+func nftConfigTables() []string {
+	if config := nftConfig(); config != "" {
+		return readNftConfigTables(config, 0)
+	}
+	return nil
+}
+
+func readNftConfigTables(path string, depth int) []string {
+	tables := []string{}
+	data, err := os.ReadFile(path)
+	if err != nil || depth > 4 {
+		return tables
+	}
+
+	for _, m := range nftTableRegexp.FindAllStringSubmatch(string(data), -1) {
+		tables = append(tables, nftTableFamily(m[1])+" "+m[2])
+	}
+	for _, m := range nftIncludeRegexp.FindAllStringSubmatch(string(data), -1) {
+		include := m[1]
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		files, _ := filepath.Glob(include)
+		for _, f := range files {
+			tables = append(tables, readNftConfigTables(f, depth+1)...)
+		}
+	}
+	return tables
+}
+
+// The family of a table is ip if it's not set
+func nftTableFamily(family string) string {
+	if family == "" {
+		return "ip"
+	}
+	return family
+}
+
+// add inserts allow rules at the top of the chain and appends the deny ones
+func (b *nftablesBackend) add(rule Rule) error {
+	chain, err := b.inputChain(rule.Source)
+	if err != nil {
+		return err
+	}
+
+	command := "add"
+	if rule.Action == RULE_ALLOW {
+		command = "insert"
+	}
+
+	args := append([]string{command, "rule", chain.Family, chain.Table, chain.Name}, nftStatement(rule)...)
+	if _, err := run("nft", args...); err != nil {
+		return err
+	}
+	return b.save()
+}
+
+// nftStatement is the rule as nft expects it e.g ip saddr 10.0.0.0/8 tcp dport 2022 accept comment "scnorion-agent"
+func nftStatement(rule Rule) []string {
+	args := []string{}
+	if rule.Source != "" {
+		selector := "ip"
+		if isIPv6(rule.Source) {
+			selector = "ip6"
+		}
+		args = append(args, selector, "saddr", rule.Source)
+	}
+
+	verdict := "drop"
+	if rule.Action == RULE_ALLOW {
+		verdict = "accept"
+	}
+	return append(args, rule.Protocol, "dport", rule.Port, verdict, "comment", `"`+RULE_COMMENT+`"`)
+}
+
+func (b *nftablesBackend) remove(rules []Rule) error {
+	for _, r := range rules {
+		if _, err := run("nft", "delete", "rule", r.ref[0], r.ref[1], r.ref[2], "handle", r.ref[3]); err != nil {
+			return err
+		}
+	}
+	return b.save()
+}
+
+// save writes the rules added by the agent to their own file, included at the end of the configuration
+// of the service so they're loaded again after a reboot. Only rules in tables of the configuration are
+// kept, nft refuses the whole file if a chain doesn't exist
+func (b *nftablesBackend) save() error {
+	config := nftConfig()
+	if config == "" {
+		return errors.New("the rules have been changed until the next reboot, no nftables configuration has been found to keep them")
+	}
+
+	_, rules, err := b.ruleset()
+	if err != nil {
+		return err
+	}
+
+	tables := readNftConfigTables(config, 0)
+	allows, denies := []string{}, []string{}
+	for _, nr := range rules {
+		if nr.Comment != RULE_COMMENT {
+			continue
+		}
+		r, ok := parseNftRule(nr)
+		if !ok || r.partial {
+			continue
+		}
+		if !slices.Contains(tables, nr.Family+" "+nr.Table) {
+			log.Printf("[INFO]: the nftables rule %s is not in a table of %s, it won't be kept after a reboot", r.Raw, config)
+			continue
+		}
+
+		statement := strings.Join(append([]string{nr.Family, nr.Table, nr.Chain}, nftStatement(r)...), " ")
+		// Allow rules are inserted at the top of the chain, the last one first to keep their order
+		if r.Action == RULE_ALLOW {
+			allows = append([]string{"insert rule " + statement}, allows...)
+		} else {
+			denies = append(denies, "add rule "+statement)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(NFTABLES_AGENT_RULES), 0755); err != nil {
+		return err
+	}
+	data := "# Rules added by scnorion-agent, this file is generated\n" + strings.Join(append(allows, denies...), "\n") + "\n"
+	if err := os.WriteFile(NFTABLES_AGENT_RULES, []byte(data), 0600); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(config)
+	if err != nil {
+		return err
+	}
+	include := `include "` + NFTABLES_AGENT_RULES + `"`
+	if strings.Contains(string(content), include) {
+		return nil
+	}
+
+	f, err := os.OpenFile(config, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString("\n" + include + "\n")
+	return err
+}
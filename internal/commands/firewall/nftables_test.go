@@ -0,0 +1,124 @@
+//go:build linux
+
+package firewall
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Ruleset of /etc/nftables.conf shipped by Debian
+const debianNftRuleset = `{"nftables": [
+{"metainfo": {"version": "1.0.6", "release_name": "Lester Gooch #5", "json_schema_version": 1}},
+{"table": {"family": "inet", "name": "filter", "handle": 1}},
+{"chain": {"family": "inet", "table": "filter", "name": "input", "handle": 1, "type": "filter", "hook": "input", "prio": 0, "policy": "accept"}},
+{"chain": {"family": "inet", "table": "filter", "name": "forward", "handle": 2, "type": "filter", "hook": "forward", "prio": 0, "policy": "accept"}},
+{"chain": {"family": "inet", "table": "filter", "name": "output", "handle": 3, "type": "filter", "hook": "output", "prio": 0, "policy": "accept"}}
+]}`
+
+// Ruleset of /etc/nftables.conf shipped by Arch Linux
+const archNftRuleset = `{"nftables": [
+{"metainfo": {"version": "1.1.1", "release_name": "Commodore Bullmoose #2", "json_schema_version": 1}},
+{"table": {"family": "inet", "name": "filter", "handle": 1}},
+{"chain": {"family": "inet", "table": "filter", "name": "input", "handle": 1, "type": "filter", "hook": "input", "prio": 0, "policy": "drop"}},
+{"chain": {"family": "inet", "table": "filter", "name": "forward", "handle": 2, "type": "filter", "hook": "forward", "prio": 0, "policy": "drop"}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 4, "comment": "early drop of invalid connections", "expr": [{"match": {"op": "in", "left": {"ct": {"key": "state"}}, "right": "invalid"}}, {"drop": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 5, "comment": "allow tracked connections", "expr": [{"match": {"op": "in", "left": {"ct": {"key": "state"}}, "right": ["established", "related"]}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 6, "comment": "allow from loopback", "expr": [{"match": {"op": "==", "left": {"meta": {"key": "iif"}}, "right": "lo"}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 7, "comment": "allow icmp", "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "ip", "field": "protocol"}}, "right": "icmp"}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 8, "comment": "allow icmp v6", "expr": [{"match": {"op": "==", "left": {"meta": {"key": "l4proto"}}, "right": "ipv6-icmp"}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 9, "comment": "allow sshd", "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 22}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 10, "expr": [{"match": {"op": "==", "left": {"meta": {"key": "pkttype"}}, "right": "host"}}, {"limit": {"rate": 5, "burst": 5, "per": "second"}}, {"counter": {"packets": 0, "bytes": 0}}, {"reject": {"type": "icmpx", "expr": "admin-prohibited"}}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 11, "expr": [{"counter": {"packets": 0, "bytes": 0}}]}}
+]}`
+
+// Arch Linux ruleset after restricting the SFTP port to the console network
+const restrictedNftRuleset = `{"nftables": [
+{"table": {"family": "inet", "name": "filter", "handle": 1}},
+{"chain": {"family": "inet", "table": "filter", "name": "input", "handle": 1, "type": "filter", "hook": "input", "prio": 0, "policy": "accept"}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 12, "comment": "scnorion-agent", "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "ip", "field": "saddr"}}, "right": {"prefix": {"addr": "10.0.0.0", "len": 8}}}}, {"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 2022}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 13, "expr": [{"match": {"op": "!=", "left": {"payload": {"protocol": "ip", "field": "saddr"}}, "right": "192.168.1.1"}}, {"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 8080}}, {"accept": null}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 14, "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": {"set": [8080, 3389]}}}, {"jump": {"target": "block"}}]}},
+{"rule": {"family": "inet", "table": "filter", "chain": "input", "handle": 15, "comment": "scnorion-agent", "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": 2022}}, {"drop": null}]}}
+]}`
+
+func TestNftablesPortAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		ruleset  string
+		rules    int
+		expected map[int]string
+	}{
+		{name: "debian default", ruleset: debianNftRuleset, rules: 0, expected: map[int]string{22: ACCESS_ANY, 2022: ACCESS_ANY}},
+		{name: "arch default", ruleset: archNftRuleset, rules: 4, expected: map[int]string{22: ACCESS_ANY, 2022: ACCESS_BLOCKED}},
+		{name: "restricted by the agent", ruleset: restrictedNftRuleset, rules: 4, expected: map[int]string{2022: ACCESS_RESTRICTED, 8080: ACCESS_UNKNOWN, 3389: ACCESS_UNKNOWN}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains, rules, err := parseNftRuleset(tt.ruleset)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			f := Firewall{}
+			readNftRuleset(&f, chains, rules)
+
+			if len(f.Rules) != tt.rules {
+				t.Errorf("expected %d rules, got %d: %+v", tt.rules, len(f.Rules), f.Rules)
+			}
+			for port, access := range tt.expected {
+				if got := f.PortAccess(port, "tcp"); got != access {
+					t.Errorf("expected %s access to port %d, got %s", access, port, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadNftConfigTables(t *testing.T) {
+	dir := t.TempDir()
+	config := `#!/usr/sbin/nft -f
+
+flush ruleset
+
+table inet filter {
+	chain input {
+		type filter hook input priority filter;
+	}
+}
+# table inet disabled {
+include "` + dir + `/*.nft"
+`
+	if err := os.WriteFile(filepath.Join(dir, "nftables.conf"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nat.nft"), []byte("table nat {\n}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := readNftConfigTables(filepath.Join(dir, "nftables.conf"), 0)
+	expected := []string{"inet filter", "ip nat"}
+	if !slices.Equal(tables, expected) {
+		t.Errorf("expected tables %v, got %v", expected, tables)
+	}
+}
+
+func TestNftStatementOfSavedRule(t *testing.T) {
+	_, rules, err := parseNftRuleset(restrictedNftRuleset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, ok := parseNftRule(rules[0])
+	if !ok {
+		t.Fatal("expected the rule added by the agent to be parsed")
+	}
+
+	expected := []string{"ip", "saddr", "10.0.0.0/8", "tcp", "dport", "2022", "accept", "comment", `"scnorion-agent"`}
+	if statement := nftStatement(r); !slices.Equal(statement, expected) {
+		t.Errorf("expected %v, got %v", expected, statement)
+	}
+}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package firewall
+
+import (
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// e.g [ 2] 2022/tcp                   ALLOW IN    10.0.0.0/8                 # scnorion-agent
+var ufwRuleRegexp = regexp.MustCompile(`^\[\s*(\d+)\]\s+(.+?)\s+(ALLOW|DENY|REJECT|LIMIT)(?:\s+(IN|OUT|FWD))?\s+(.+?)(?:\s+#\s*(.*))?$`)
+
+// e.g Default: deny (incoming), allow (outgoing), disabled (routed)
+var ufwDefaultRegexp = regexp.MustCompile(`(\w+) \((incoming|outgoing|routed)\)`)
+
+var ufwPortRegexp = regexp.MustCompile(`^[\d,:]+(/(tcp|udp))?$`)
+
+type ufwBackend struct{}
+
+func (b *ufwBackend) name() string {
+	return BACKEND_UFW
+}
+
+func (b *ufwBackend) active() bool {
+	out, err := run("ufw", "status")
+	return err == nil && strings.Contains(out, "Status: active")
+}
+
+func (b *ufwBackend) status(f *Firewall) error {
+	out, err := run("ufw", "status", "verbose")
+	if err != nil {
+		return err
+	}
+
+	f.Enabled = strings.Contains(out, "Status: active")
+	for line := range strings.SplitSeq(out, "\n") {
+		if !strings.HasPrefix(line, "Default:") {
+			continue
+		}
+		for _, m := range ufwDefaultRegexp.FindAllStringSubmatch(line, -1) {
+			switch m[2] {
+			case "incoming":
+				f.DefaultPolicies.Incoming = m[1]
+			case "outgoing":
+				f.DefaultPolicies.Outgoing = m[1]
+			case "routed":
+				f.DefaultPolicies.Routed = m[1]
+			}
+		}
+	}
+
+	// Rules are only listed if ufw is active
+	if !f.Enabled {
+		return nil
+	}
+
+	out, err = run("ufw", "status", "numbered")
+	if err != nil {
+		return err
+	}
+
+	for line := range strings.SplitSeq(out, "\n") {
+		m := ufwRuleRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+
+		r := Rule{
+			Direction: DIRECTION_IN,
+			Action:    strings.ToLower(m[3]),
+			Managed:   m[6] == RULE_COMMENT,
+			Raw:       strings.TrimSpace(line),
+			ref:       []string{m[1]},
+		}
+		switch m[4] {
+		case "OUT":
+			r.Direction = DIRECTION_OUT
+		case "FWD":
+			r.Direction = DIRECTION_FORWARD
+		}
+
+		to, iface, _ := strings.Cut(strings.ReplaceAll(m[2], " (v6)", ""), " on ")
+		r.Interface = strings.TrimSpace(iface)
+		for _, field := range strings.Fields(to) {
+			switch {
+			case field == "Anywhere":
+			case ufwPortRegexp.MatchString(field):
+				r.Port, r.Protocol, _ = strings.Cut(field, "/")
+			case isAddress(field):
+				r.Destination = field
+			default:
+				// Application profile e.g OpenSSH
+				r.Port = field
+			}
+		}
+
+		from := strings.Fields(strings.ReplaceAll(m[5], " (v6)", ""))
+		if len(from) > 0 && from[0] != "Anywhere" {
+			r.Source = from[0]
+		}
+
+		f.Rules = append(f.Rules, r)
+	}
+	return nil
+}
+
+func (b *ufwBackend) setEnabled(enabled bool) error {
+	if enabled {
+		_, err := run("ufw", "--force", "enable")
+		return err
+	}
+	_, err := run("ufw", "disable")
+	return err
+}
+
+func (b *ufwBackend) add(rule Rule) error {
+	source := rule.Source
+	if source == "" {
+		source = "any"
+	}
+	_, err := run("ufw", rule.Action, "proto", rule.Protocol, "from", source, "to", "any", "port", rule.Port, "comment", RULE_COMMENT)
+	return err
+}
+
+// remove deletes from the last rule as ufw renumbers the rules after a delete
+func (b *ufwBackend) remove(rules []Rule) error {
+	numbers := []int{}
+	for _, r := range rules {
+		if n, err := strconv.Atoi(r.ref[0]); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	slices.Sort(numbers)
+	slices.Reverse(numbers)
+
+	for _, n := range numbers {
+		if _, err := run("ufw", "--force", "delete", strconv.Itoa(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isAddress(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
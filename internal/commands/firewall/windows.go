@@ -0,0 +1,13 @@
+//go:build windows
+
+package firewall
+
+import "errors"
+
+func Get() (*Firewall, error) {
+	return nil, errors.New("firewall inventory is not supported on this operating system")
+}
+
+func Control(req FirewallRequest) (*Firewall, error) {
+	return nil, errors.New("firewall control is not supported on this operating system")
+}
@@ -0,0 +1,143 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+const APK_INSTALLED_DB = "/lib/apk/db/installed"
+
+type Apk struct{}
+
+func (pm *Apk) Name() string {
+	return "apk"
+}
+
+func (pm *Apk) Available() bool {
+	return commandExists("apk") && pathExists(APK_INSTALLED_DB)
+}
+
+func (pm *Apk) Install(packageID string) error {
+	return pm.runLocked("add", packageID)
+}
+
+func (pm *Apk) Update(packageID string) error {
+	return pm.runLocked("upgrade", packageID)
+}
+
+func (pm *Apk) Remove(packageID string) error {
+	return pm.runLocked("del", packageID)
+}
+
+// runLocked relies on apk --wait that blocks until the database lock is released
+func (pm *Apk) runLocked(args ...string) error {
+	wait := strconv.Itoa(int(PACKAGE_LOCK_TIMEOUT.Seconds()))
+	_, err := run(nil, "apk", append([]string{"--quiet", "--no-progress", "--wait", wait}, args...)...)
+	return err
+}
+
+// PendingUpdates parses apk version output
+// e.g openssl-3.3.1-r0 < 3.3.2-r0
+func (pm *Apk) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	if _, err := run(nil, "apk", "update", "--quiet"); err != nil {
+		return nil, err
+	}
+
+	out, err := run(nil, "apk", "version", "-l", "<")
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "<" {
+			continue
+		}
+
+		// Name and version are joined by a dash, versions always have a -rN release suffix
+		name, current := fields[0], ""
+		parts := strings.Split(fields[0], "-")
+		if len(parts) >= 3 {
+			name = strings.Join(parts[:len(parts)-2], "-")
+			current = strings.Join(parts[len(parts)-2:], "-")
+		}
+
+		updates = append(updates, PendingUpdate{
+			Name:           name,
+			CurrentVersion: current,
+			NewVersion:     fields[2],
+		})
+	}
+
+	return updates, nil
+}
+
+// List parses the installed database, packages are blocks of single letter fields
+// where F: is a folder and R: a file inside the last folder. Alpine doesn't store when a
+// package was installed, t: is the build time so the install date is left empty
+func (pm *Apk) List() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	f, err := os.Open(APK_INSTALLED_DB)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	app := scnorion_nats.Application{}
+	folder := ""
+	hasDesktopEntry := false
+
+	addPackage := func() {
+		if app.Name != "" && hasDesktopEntry {
+			apps = append(apps, app)
+		}
+		app = scnorion_nats.Application{}
+		folder = ""
+		hasDesktopEntry = false
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			addPackage()
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "P":
+			app.Name = value
+		case "V":
+			app.Version = value
+		case "m":
+			app.Publisher = stripEmail(value)
+		case "F":
+			folder = value
+		case "R":
+			if isDesktopEntry("/" + folder + "/" + value) {
+				hasDesktopEntry = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	addPackage()
+
+	return apps, nil
+}
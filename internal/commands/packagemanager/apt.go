@@ -0,0 +1,247 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+const (
+	DPKG_STATUS_FILE = "/var/lib/dpkg/status"
+	DPKG_INFO_DIR    = "/var/lib/dpkg/info"
+)
+
+var AptEnv = []string{"DEBIAN_FRONTEND=noninteractive"}
+
+type Apt struct{}
+
+func (pm *Apt) Name() string {
+	return "apt"
+}
+
+func (pm *Apt) Available() bool {
+	return commandExists("apt-get") && pathExists(DPKG_STATUS_FILE)
+}
+
+func (pm *Apt) Install(packageID string) error {
+	return pm.runLocked("install", packageID)
+}
+
+func (pm *Apt) Update(packageID string) error {
+	return pm.runLocked("install", "--only-upgrade", packageID)
+}
+
+func (pm *Apt) Remove(packageID string) error {
+	return pm.runLocked("remove", packageID)
+}
+
+// runLocked waits for the dpkg lock and runs apt-get, apt-get also waits by itself for the lock
+// if it's taken again between our check and the command
+func (pm *Apt) runLocked(args ...string) error {
+	if err := waitForLock(pm.Name(), func() (bool, string) { return isFcntlLocked(dpkgLocks) }); err != nil {
+		return err
+	}
+
+	lockTimeout := fmt.Sprintf("DPkg::Lock::Timeout=%d", int(PACKAGE_LOCK_TIMEOUT.Seconds()))
+	args = append([]string{"--yes", "--quiet", "-o", lockTimeout}, args...)
+	_, err := run(AptEnv, "apt-get", args...)
+	return err
+}
+
+// PendingUpdates parses apt list --upgradable
+// e.g openssl/jammy-security 3.0.2-0ubuntu1.18 amd64 [upgradable from: 3.0.2-0ubuntu1.17]
+func (pm *Apt) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	if _, err := run(AptEnv, "apt-get", "update", "--quiet"); err != nil {
+		return nil, err
+	}
+
+	out, err := run(AptEnv, "apt", "list", "--upgradable")
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+
+		name, suites, _ := strings.Cut(fields[0], "/")
+		update := PendingUpdate{
+			Name:       name,
+			NewVersion: fields[1],
+			Source:     suites,
+			Security:   strings.Contains(suites, "-security"),
+		}
+
+		if _, from, found := strings.Cut(line, "upgradable from: "); found {
+			update.CurrentVersion = strings.TrimSuffix(strings.TrimSpace(from), "]")
+		}
+
+		updates = append(updates, update)
+	}
+
+	// Debian and Ubuntu don't store advisories locally, debsecan knows them if it's installed
+	advisories := debsecanAdvisories()
+	for i := range updates {
+		if a, ok := advisories[updates[i].Name]; ok {
+			updates[i].Security = true
+			updates[i].Severity = a.Severity
+			updates[i].Advisories = a.Advisories
+		} else if updates[i].Security {
+			updates[i].Severity = SEVERITY_UNKNOWN
+		}
+	}
+
+	return updates, nil
+}
+
+// debsecanAdvisories returns the CVEs fixed by the pending updates by package name
+// e.g CVE-2024-2511 openssl (fixed, low urgency)
+func debsecanAdvisories() map[string]PendingUpdate {
+	advisories := map[string]PendingUpdate{}
+
+	if !commandExists("debsecan") {
+		return advisories
+	}
+
+	out, err := run(nil, "debsecan", "--only-fixed", "--format", "summary")
+	if err != nil {
+		return advisories
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		severity := SEVERITY_UNKNOWN
+		if _, flags, found := strings.Cut(line, "("); found {
+			for _, flag := range strings.Split(strings.TrimSuffix(strings.TrimSpace(flags), ")"), ",") {
+				if urgency, found := strings.CutSuffix(strings.TrimSpace(flag), " urgency"); found {
+					severity = NormalizeSeverity(urgency)
+				}
+			}
+		}
+
+		a := advisories[fields[1]]
+		a.Severity = higherSeverity(a.Severity, severity)
+		a.Advisories = append(a.Advisories, fields[0])
+		advisories[fields[1]] = a
+	}
+
+	return advisories
+}
+
+// List parses the dpkg status file, each package is a stanza separated by a blank line
+func (pm *Apt) List() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	f, err := os.Open(DPKG_STATUS_FILE)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stanza := map[string]string{}
+
+	addPackage := func() {
+		defer func() { stanza = map[string]string{} }()
+
+		// Held packages are installed too e.g hold ok installed
+		if !strings.HasSuffix(stanza["Status"], " ok installed") || stanza["Package"] == "" {
+			return
+		}
+
+		listFile, found := getDpkgListFile(stanza["Package"], stanza["Architecture"])
+		if !found || !dpkgListHasDesktopEntry(listFile) {
+			return
+		}
+
+		app := scnorion_nats.Application{
+			Name:    stanza["Package"],
+			Version: stanza["Version"],
+		}
+
+		for _, key := range []string{"Original-Maintainer", "Vendor", "Maintainer"} {
+			if publisher := stripEmail(stanza[key]); publisher != "" {
+				app.Publisher = publisher
+				break
+			}
+		}
+
+		// dpkg doesn't store the install date, the list file is written when the package is unpacked
+		if info, err := os.Stat(listFile); err == nil {
+			app.InstallDate = info.ModTime().Format("20060102")
+		}
+
+		apps = append(apps, app)
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			addPackage()
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			// Continuation of a multiline field (e.g Description or Conffiles), we don't need them
+			continue
+		default:
+			key, value, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			stanza[key] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// The file may not end with a blank line
+	addPackage()
+
+	return apps, nil
+}
+
+// getDpkgListFile returns the file list of the package, multiarch packages use the pkg:arch.list name
+func getDpkgListFile(pkg, arch string) (string, bool) {
+	candidates := []string{filepath.Join(DPKG_INFO_DIR, pkg+".list")}
+	if arch != "" {
+		candidates = append(candidates, filepath.Join(DPKG_INFO_DIR, pkg+":"+arch+".list"))
+	}
+
+	for _, c := range candidates {
+		if pathExists(c) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func dpkgListHasDesktopEntry(listFile string) bool {
+	f, err := os.Open(listFile)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if isDesktopEntry(scanner.Text()) {
+			return true
+		}
+	}
+	return false
+}
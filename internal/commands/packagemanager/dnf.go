@@ -0,0 +1,139 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+// dnf check-update exits with 100 when there are updates available
+const DNF_UPDATES_AVAILABLE = 100
+
+type Dnf struct{}
+
+func (pm *Dnf) Name() string {
+	return "dnf"
+}
+
+func (pm *Dnf) Available() bool {
+	return commandExists("dnf") && commandExists("rpm")
+}
+
+func (pm *Dnf) List() ([]scnorion_nats.Application, error) {
+	return listRPMApplications()
+}
+
+func (pm *Dnf) Install(packageID string) error {
+	return pm.runLocked("install", packageID)
+}
+
+func (pm *Dnf) Update(packageID string) error {
+	return pm.runLocked("upgrade", packageID)
+}
+
+func (pm *Dnf) Remove(packageID string) error {
+	return pm.runLocked("remove", packageID)
+}
+
+// runLocked waits for other dnf instances (e.g dnf-automatic) and the rpm database lock
+func (pm *Dnf) runLocked(args ...string) error {
+	if err := waitForLock(pm.Name(), func() (bool, string) {
+		if locked, holder := isPIDFileLocked(dnfPIDFiles()); locked {
+			return locked, holder
+		}
+		return isFcntlLocked(rpmLocks)
+	}); err != nil {
+		return err
+	}
+
+	_, err := run(nil, "dnf", append([]string{"--assumeyes", "--quiet"}, args...)...)
+	return err
+}
+
+// PendingUpdates parses dnf check-update
+// e.g openssl.x86_64    1:3.0.7-25.el9    baseos
+func (pm *Dnf) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	out, err := runAllowingExitCodes([]int{DNF_UPDATES_AVAILABLE}, "dnf", "check-update", "--refresh", "--quiet")
+	if err != nil {
+		return nil, err
+	}
+
+	advisories := pm.advisories()
+	installed := installedRPMVersions()
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		// Obsoleted packages are listed after the updates
+		if strings.HasPrefix(line, "Obsoleting") {
+			break
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i]
+		}
+
+		update := PendingUpdate{
+			Name:           name,
+			CurrentVersion: installed[name],
+			NewVersion:     fields[1],
+			Source:         fields[2],
+		}
+		if a, ok := advisories[name]; ok {
+			update.Security = a.Security
+			update.Severity = a.Severity
+			update.Advisories = a.Advisories
+		}
+
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// advisories returns the advisories of the pending updates by package name
+// dnf4: FEDORA-2024-1234 Important/Sec. openssl-1:3.0.7-25.el9.x86_64
+// dnf5: FEDORA-2024-1234 security Important openssl-1:3.0.7-25.el9.x86_64 2024-10-01 10:00:00
+func (pm *Dnf) advisories() map[string]PendingUpdate {
+	advisories := map[string]PendingUpdate{}
+
+	out, err := run(nil, "dnf", "updateinfo", "list", "--updates", "--quiet")
+	if err != nil {
+		return advisories
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] == "Name" {
+			continue
+		}
+
+		id, nevra, security, severity := fields[0], fields[2], false, ""
+		if kind, sev, found := strings.Cut(fields[1], "/"); found && sev == "Sec." {
+			security, severity = true, NormalizeSeverity(kind)
+		} else if fields[1] == "security" && len(fields) >= 4 {
+			security, severity, nevra = true, NormalizeSeverity(fields[2]), fields[3]
+		} else if len(fields) >= 4 && strings.Contains(fields[3], "-") {
+			nevra = fields[3]
+		}
+
+		name := rpmNameFromNEVRA(nevra)
+		a := advisories[name]
+		a.Security = a.Security || security
+		if security {
+			a.Severity = higherSeverity(a.Severity, severity)
+		}
+		a.Advisories = append(a.Advisories, id)
+		advisories[name] = a
+	}
+
+	return advisories
+}
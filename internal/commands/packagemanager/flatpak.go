@@ -0,0 +1,103 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+const FLATHUB_REPO = "https://flathub.org/repo/flathub.flatpakrepo"
+
+type Flatpak struct{}
+
+func (pm *Flatpak) Name() string {
+	return "flatpak"
+}
+
+func (pm *Flatpak) Available() bool {
+	return commandExists("flatpak")
+}
+
+func (pm *Flatpak) addFlathub() error {
+	_, err := run(nil, "flatpak", "remote-add", "--system", "--if-not-exists", "flathub", FLATHUB_REPO)
+	return err
+}
+
+func (pm *Flatpak) Install(packageID string) error {
+	if err := pm.addFlathub(); err != nil {
+		return err
+	}
+	_, err := run(nil, "flatpak", "install", "--system", "--noninteractive", "--assumeyes", "flathub", packageID)
+	return err
+}
+
+func (pm *Flatpak) Update(packageID string) error {
+	if err := pm.addFlathub(); err != nil {
+		return err
+	}
+	_, err := run(nil, "flatpak", "update", "--system", "--noninteractive", "--assumeyes", packageID)
+	return err
+}
+
+func (pm *Flatpak) Remove(packageID string) error {
+	if err := pm.addFlathub(); err != nil {
+		return err
+	}
+	_, err := run(nil, "flatpak", "remove", "--system", "--noninteractive", "--assumeyes", packageID)
+	return err
+}
+
+// List returns the apps installed system wide, runtimes are not applications so they're skipped
+func (pm *Flatpak) List() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	out, err := run(nil, "flatpak", "list", "--system", "--app", "--columns=name,version")
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		name, version, _ := strings.Cut(line, "\t")
+		app := scnorion_nats.Application{
+			Name:      strings.TrimSpace(name),
+			Version:   strings.TrimSpace(version),
+			Publisher: "Flatpak",
+		}
+		if app.Version == "" {
+			app.Version = "-"
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+func (pm *Flatpak) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	out, err := run(nil, "flatpak", "remote-ls", "--system", "--updates", "--app", "--columns=application,version,origin")
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		columns := strings.Split(line, "\t")
+		if len(columns) < 3 || strings.TrimSpace(columns[0]) == "" {
+			continue
+		}
+		updates = append(updates, PendingUpdate{
+			Name:       strings.TrimSpace(columns[0]),
+			NewVersion: strings.TrimSpace(columns[1]),
+			Source:     strings.TrimSpace(columns[2]),
+		})
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,101 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Unattended upgrades or a user with a package manager open may hold the lock for a while
+const PACKAGE_LOCK_TIMEOUT = 5 * time.Minute
+const PACKAGE_LOCK_POLL_INTERVAL = 5 * time.Second
+
+var (
+	dpkgLocks   = []string{"/var/lib/dpkg/lock-frontend", "/var/lib/dpkg/lock"}
+	rpmLocks    = []string{"/var/lib/rpm/.rpm.lock", "/usr/lib/sysimage/rpm/.rpm.lock"}
+	pacmanLock  = "/var/lib/pacman/db.lck"
+	zypperPID   = "/run/zypp.pid"
+	dnfPIDGlobs = []string{"/var/cache/dnf/*.pid", "/run/dnf/*.pid"}
+)
+
+// waitForLock polls until isLocked returns false or the timeout is reached
+func waitForLock(manager string, isLocked func() (bool, string)) error {
+	deadline := time.Now().Add(PACKAGE_LOCK_TIMEOUT)
+	for {
+		locked, holder := isLocked()
+		if !locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return &CommandError{
+				Manager: manager,
+				Err:     fmt.Errorf("%w: %s after %v", ErrLockTimeout, holder, PACKAGE_LOCK_TIMEOUT),
+			}
+		}
+		time.Sleep(PACKAGE_LOCK_POLL_INTERVAL)
+	}
+}
+
+// isFcntlLocked checks if another process holds a fcntl write lock on the file, dpkg and rpm use these locks
+func isFcntlLocked(paths []string) (bool, string) {
+	for _, path := range paths {
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			continue
+		}
+
+		lock := unix.Flock_t{Type: unix.F_WRLCK, Whence: 0, Start: 0, Len: 0}
+		err = unix.FcntlFlock(f.Fd(), unix.F_GETLK, &lock)
+		f.Close()
+		if err == nil && lock.Type != unix.F_UNLCK {
+			return true, fmt.Sprintf("%s is locked by pid %d", path, lock.Pid)
+		}
+	}
+	return false, ""
+}
+
+// isPIDFileLocked checks if the process written in a pid file is still alive
+func isPIDFileLocked(paths []string) (bool, string) {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 || pid == os.Getpid() {
+			continue
+		}
+
+		if err := syscall.Kill(pid, 0); err == nil || err == syscall.EPERM {
+			return true, fmt.Sprintf("%s is held by pid %d", path, pid)
+		}
+	}
+	return false, ""
+}
+
+// isLockFilePresent is used by package managers that create a lock file while they run
+func isLockFilePresent(path string) (bool, string) {
+	if pathExists(path) {
+		return true, fmt.Sprintf("%s exists", path)
+	}
+	return false, ""
+}
+
+func dnfPIDFiles() []string {
+	files := []string{}
+	for _, g := range dnfPIDGlobs {
+		if matches, err := filepath.Glob(g); err == nil {
+			files = append(files, matches...)
+		}
+	}
+	return files
+}
@@ -0,0 +1,207 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+// PackageManager is implemented by every package manager backend that the agent can drive
+type PackageManager interface {
+	// Name is the identifier of the backend e.g apt, dnf, flatpak
+	Name() string
+	// Available reports if the package manager is installed and usable in this system
+	Available() bool
+	// List returns the installed packages that ship a desktop application
+	List() ([]scnorion_nats.Application, error)
+	Install(packageID string) error
+	Update(packageID string) error
+	Remove(packageID string) error
+	PendingUpdates() ([]PendingUpdate, error)
+}
+
+var (
+	ErrNoPackageManager = errors.New("no supported package manager has been found")
+	ErrLockTimeout      = errors.New("timed out waiting for the package manager lock")
+)
+
+// Errors keep only the end of the output, package managers can be very verbose
+const MAX_ERROR_OUTPUT_LINES = 20
+
+// System package managers in order of preference, some distributions ship more than one
+// (e.g openSUSE can have dnf installed) so the first available wins
+var systemBackends = []PackageManager{
+	&Apt{},
+	&Zypper{},
+	&Dnf{},
+	&Pacman{},
+	&Apk{},
+}
+
+// Application package managers that can coexist with the system one
+var applicationBackends = []PackageManager{
+	&Flatpak{},
+	&Snap{},
+}
+
+// System returns the package manager that owns the operating system packages
+func System() (PackageManager, error) {
+	for _, pm := range systemBackends {
+		if pm.Available() {
+			return pm, nil
+		}
+	}
+	return nil, ErrNoPackageManager
+}
+
+// Available returns all the package managers found in this system
+func Available() []PackageManager {
+	backends := []PackageManager{}
+	for _, pm := range append(systemBackends, applicationBackends...) {
+		if pm.Available() {
+			backends = append(backends, pm)
+		}
+	}
+	return backends
+}
+
+// Get returns the backend with that name if it's available in this system
+func Get(name string) (PackageManager, error) {
+	for _, pm := range append(systemBackends, applicationBackends...) {
+		if pm.Name() == name {
+			if !pm.Available() {
+				return nil, fmt.Errorf("package manager %s is not available", name)
+			}
+			return pm, nil
+		}
+	}
+	return nil, fmt.Errorf("package manager %s is not supported", name)
+}
+
+func commandExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CommandError keeps what the package manager said so the console can show why a deployment failed
+type CommandError struct {
+	Manager  string `json:"manager"`
+	Command  string `json:"command,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output,omitempty"`
+	Err      error  `json:"-"`
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("%s failed", e.Manager)
+	if e.Command != "" {
+		msg = fmt.Sprintf("%s failed with exit code %d", e.Command, e.ExitCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	if e.Output != "" {
+		msg += ", output: " + e.Output
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// run executes a package manager command returning its output in the error as these tools explain there what went wrong
+func run(env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		cmdErr := &CommandError{
+			Manager:  name,
+			Command:  name + " " + strings.Join(args, " "),
+			ExitCode: -1,
+			Output:   LastLines(string(out), MAX_ERROR_OUTPUT_LINES),
+			Err:      err,
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			cmdErr.ExitCode = exitErr.ExitCode()
+			cmdErr.Err = nil
+		}
+		return out, cmdErr
+	}
+	return out, nil
+}
+
+// LastLines keeps the end of the output where package managers print the errors
+func LastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runAllowingExitCodes is like run but treats some exit codes as success, dnf and zypper
+// return non zero codes to tell that updates are available
+func runAllowingExitCodes(codes []int, name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && slices.Contains(codes, exitErr.ExitCode()) {
+		return out, nil
+	}
+	if err != nil {
+		return out, fmt.Errorf("%s %s failed: %v", name, strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+func isDesktopEntry(path string) bool {
+	return strings.HasSuffix(path, ".desktop") && strings.Contains(path, "/applications/")
+}
+
+// NormalizeSeverity maps the severities and urgencies used by the different tools
+func NormalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "urgent":
+		return SEVERITY_CRITICAL
+	case "important", "high":
+		return SEVERITY_IMPORTANT
+	case "moderate", "medium":
+		return SEVERITY_MODERATE
+	case "low", "negligible":
+		return SEVERITY_LOW
+	default:
+		return SEVERITY_UNKNOWN
+	}
+}
+
+// higherSeverity returns the most severe of both
+func higherSeverity(a, b string) string {
+	rank := map[string]int{"": 0, SEVERITY_UNKNOWN: 1, SEVERITY_LOW: 2, SEVERITY_MODERATE: 3, SEVERITY_IMPORTANT: 4, SEVERITY_CRITICAL: 5}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// stripEmail removes the email from a maintainer e.g John Doe <john@example.com>
+func stripEmail(maintainer string) string {
+	if name, _, found := strings.Cut(maintainer, "<"); found {
+		return strings.TrimSpace(name)
+	}
+	return strings.TrimSpace(maintainer)
+}
@@ -0,0 +1,172 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+const PACMAN_LOCAL_DIR = "/var/lib/pacman/local"
+
+type Pacman struct{}
+
+func (pm *Pacman) Name() string {
+	return "pacman"
+}
+
+func (pm *Pacman) Available() bool {
+	return commandExists("pacman") && pathExists(PACMAN_LOCAL_DIR)
+}
+
+// Install uses the current sync databases, refreshing them without upgrading
+// the system is a partial upgrade that Arch doesn't support
+func (pm *Pacman) Install(packageID string) error {
+	return pm.runLocked("--sync", "--needed", packageID)
+}
+
+// Update needs fresh databases so the whole system is upgraded with the package
+func (pm *Pacman) Update(packageID string) error {
+	return pm.runLocked("--sync", "--refresh", "--sysupgrade", "--needed", packageID)
+}
+
+func (pm *Pacman) Remove(packageID string) error {
+	return pm.runLocked("--remove", packageID)
+}
+
+// runLocked waits for the database lock file, pacman fails at once if it exists
+func (pm *Pacman) runLocked(args ...string) error {
+	if err := waitForLock(pm.Name(), func() (bool, string) { return isLockFilePresent(pacmanLock) }); err != nil {
+		return err
+	}
+
+	_, err := run(nil, "pacman", append([]string{"--noconfirm"}, args...)...)
+	return err
+}
+
+// PendingUpdates parses the list of upgradable packages
+// e.g openssl 3.3.1-1 -> 3.3.2-1
+func (pm *Pacman) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	// checkupdates (pacman-contrib) syncs a temporary copy of the databases so it's preferred,
+	// it exits with 2 when there are no updates
+	var out []byte
+	var err error
+	if commandExists("checkupdates") {
+		out, err = runAllowingExitCodes([]int{2}, "checkupdates")
+	} else {
+		// pacman -Qu exits with 1 when there are no updates
+		out, err = runAllowingExitCodes([]int{1}, "pacman", "--query", "--upgrades")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[2] != "->" {
+			continue
+		}
+		updates = append(updates, PendingUpdate{
+			Name:           fields[0],
+			CurrentVersion: fields[1],
+			NewVersion:     fields[3],
+		})
+	}
+
+	return updates, nil
+}
+
+// List reads the local database, every package has a folder with desc and files
+func (pm *Pacman) List() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	entries, err := os.ReadDir(PACMAN_LOCAL_DIR)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		pkgDir := filepath.Join(PACMAN_LOCAL_DIR, e.Name())
+		files, err := readPacmanSections(filepath.Join(pkgDir, "files"))
+		if err != nil {
+			continue
+		}
+
+		hasDesktopEntry := false
+		for _, f := range files["FILES"] {
+			// pacman stores paths without the leading slash
+			if isDesktopEntry("/" + f) {
+				hasDesktopEntry = true
+				break
+			}
+		}
+		if !hasDesktopEntry {
+			continue
+		}
+
+		desc, err := readPacmanSections(filepath.Join(pkgDir, "desc"))
+		if err != nil {
+			continue
+		}
+
+		app := scnorion_nats.Application{
+			Name:      firstValue(desc["NAME"]),
+			Version:   firstValue(desc["VERSION"]),
+			Publisher: stripEmail(firstValue(desc["PACKAGER"])),
+		}
+
+		if installDate, err := strconv.ParseInt(firstValue(desc["INSTALLDATE"]), 10, 64); err == nil {
+			app.InstallDate = time.Unix(installDate, 0).Format("20060102")
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// readPacmanSections parses files with the %SECTION% header followed by one value per line
+func readPacmanSections(path string) (map[string][]string, error) {
+	sections := map[string][]string{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			section = ""
+		case strings.HasPrefix(line, "%") && strings.HasSuffix(line, "%") && len(line) > 2:
+			section = strings.Trim(line, "%")
+		case section != "":
+			sections[section] = append(sections[section], line)
+		}
+	}
+
+	return sections, scanner.Err()
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,115 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+// Only packages shipping a desktop entry are reported as applications
+var desktopEntriesGlobs = []string{
+	"/usr/share/applications/*.desktop",
+	"/usr/local/share/applications/*.desktop",
+}
+
+// listRPMApplications queries the rpm database once for the owners of the desktop entries
+// and once for the information of all the installed packages, it's shared by dnf and zypper
+func listRPMApplications() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	desktopFiles := []string{}
+	for _, g := range desktopEntriesGlobs {
+		matches, err := filepath.Glob(g)
+		if err == nil {
+			desktopFiles = append(desktopFiles, matches...)
+		}
+	}
+	if len(desktopFiles) == 0 {
+		return apps, nil
+	}
+
+	// rpm exits with an error if any file is not owned by a package, but the output is still valid
+	args := append([]string{"-qf", "--queryformat", "%{NAME}\n"}, desktopFiles...)
+	out, err := exec.Command("rpm", args...).Output()
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+
+	owners := map[string]bool{}
+	for line := range strings.SplitSeq(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.Contains(line, "not owned by any package") {
+			continue
+		}
+		owners[line] = true
+	}
+
+	out, err = exec.Command("rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}\t%{VENDOR}\t%{PACKAGER}\t%{INSTALLTIME}\n").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 || !owners[fields[0]] {
+			continue
+		}
+
+		// Several versions of a package can be installed (e.g kernels) so we report each only once
+		delete(owners, fields[0])
+
+		app := scnorion_nats.Application{
+			Name:    fields[0],
+			Version: fields[1],
+		}
+
+		app.Publisher = fields[2]
+		if app.Publisher == "(none)" || app.Publisher == "" {
+			app.Publisher = stripEmail(fields[3])
+		}
+		if app.Publisher == "(none)" {
+			app.Publisher = ""
+		}
+
+		if installTime, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+			app.InstallDate = time.Unix(installTime, 0).Format("20060102")
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// rpmNameFromNEVRA removes the version, release and arch from a package
+// e.g openssl-1:3.0.7-25.el9.x86_64 -> openssl
+func rpmNameFromNEVRA(nevra string) string {
+	parts := strings.Split(nevra, "-")
+	if len(parts) < 3 {
+		return nevra
+	}
+	return strings.Join(parts[:len(parts)-2], "-")
+}
+
+// installedRPMVersions returns the version-release of the installed packages by name
+func installedRPMVersions() map[string]string {
+	versions := map[string]string{}
+
+	out, err := exec.Command("rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}-%{RELEASE}\n").Output()
+	if err != nil {
+		return versions
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		if name, version, found := strings.Cut(line, "\t"); found {
+			versions[name] = version
+		}
+	}
+	return versions
+}
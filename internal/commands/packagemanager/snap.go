@@ -0,0 +1,105 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+type Snap struct{}
+
+func (pm *Snap) Name() string {
+	return "snap"
+}
+
+func (pm *Snap) Available() bool {
+	return commandExists("snap") && pathExists("/run/snapd.socket")
+}
+
+func (pm *Snap) Install(packageID string) error {
+	_, err := run(nil, "snap", "install", packageID)
+	return err
+}
+
+func (pm *Snap) Update(packageID string) error {
+	_, err := run(nil, "snap", "refresh", packageID)
+	return err
+}
+
+func (pm *Snap) Remove(packageID string) error {
+	_, err := run(nil, "snap", "remove", packageID)
+	return err
+}
+
+// List parses snap list
+// e.g firefox  131.0.3-1  5091  latest/stable/…  mozilla**  -
+func (pm *Snap) List() ([]scnorion_nats.Application, error) {
+	apps := []scnorion_nats.Application{}
+
+	out, err := run(nil, "snap", "list", "--unicode=never")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fields := range snapTableRows(out) {
+		if len(fields) < 5 {
+			continue
+		}
+		apps = append(apps, scnorion_nats.Application{
+			Name:      fields[0],
+			Version:   fields[1],
+			Publisher: snapPublisher(fields[4]),
+		})
+	}
+
+	return apps, nil
+}
+
+// PendingUpdates parses snap refresh --list
+// e.g firefox  132.0-1  5187  270MB  mozilla**  -
+func (pm *Snap) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	out, err := run(nil, "snap", "refresh", "--list", "--unicode=never")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fields := range snapTableRows(out) {
+		if len(fields) < 2 {
+			continue
+		}
+		updates = append(updates, PendingUpdate{
+			Name:       fields[0],
+			NewVersion: fields[1],
+			Source:     "snapcraft",
+		})
+	}
+
+	return updates, nil
+}
+
+// snapTableRows skips the header, snap prints a message instead of the table if it's empty
+func snapTableRows(out []byte) [][]string {
+	rows := [][]string{}
+
+	lines := strings.Split(string(out), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "Name") {
+		return rows
+	}
+
+	for _, line := range lines[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows = append(rows, fields)
+		}
+	}
+
+	return rows
+}
+
+// snapPublisher removes the verified (**) and starred (*) marks
+func snapPublisher(publisher string) string {
+	return strings.TrimRight(publisher, "*")
+}
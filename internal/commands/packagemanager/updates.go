@@ -0,0 +1,21 @@
+package packagemanager
+
+// Severities are normalized to the Red Hat scale that dnf and zypper already use
+const (
+	SEVERITY_CRITICAL  = "critical"
+	SEVERITY_IMPORTANT = "important"
+	SEVERITY_MODERATE  = "moderate"
+	SEVERITY_LOW       = "low"
+	SEVERITY_UNKNOWN   = "unknown"
+)
+
+// PendingUpdate is shared with the report on every operating system
+type PendingUpdate struct {
+	Name           string   `json:"name"`
+	CurrentVersion string   `json:"current_version,omitempty"`
+	NewVersion     string   `json:"new_version,omitempty"`
+	Source         string   `json:"source,omitempty"`
+	Security       bool     `json:"security"`
+	Severity       string   `json:"severity,omitempty"`
+	Advisories     []string `json:"advisories,omitempty"`
+}
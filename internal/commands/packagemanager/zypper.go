@@ -0,0 +1,131 @@
+//go:build linux
+
+package packagemanager
+
+import (
+	"fmt"
+	"strings"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+// zypper uses exit codes from 100 to tell that updates or patches are needed
+var zypperInfoExitCodes = []int{100, 101, 102, 103}
+
+type Zypper struct{}
+
+func (pm *Zypper) Name() string {
+	return "zypper"
+}
+
+func (pm *Zypper) Available() bool {
+	return commandExists("zypper") && commandExists("rpm")
+}
+
+func (pm *Zypper) List() ([]scnorion_nats.Application, error) {
+	return listRPMApplications()
+}
+
+func (pm *Zypper) Install(packageID string) error {
+	return pm.runLocked("install", "--auto-agree-with-licenses", packageID)
+}
+
+func (pm *Zypper) Update(packageID string) error {
+	return pm.runLocked("update", "--auto-agree-with-licenses", packageID)
+}
+
+func (pm *Zypper) Remove(packageID string) error {
+	return pm.runLocked("remove", packageID)
+}
+
+// runLocked waits for the zypp lock, ZYPP_LOCK_TIMEOUT makes zypper wait too if the lock is taken again
+func (pm *Zypper) runLocked(args ...string) error {
+	if err := waitForLock(pm.Name(), func() (bool, string) { return isPIDFileLocked([]string{zypperPID}) }); err != nil {
+		return err
+	}
+
+	env := []string{fmt.Sprintf("ZYPP_LOCK_TIMEOUT=%d", int(PACKAGE_LOCK_TIMEOUT.Seconds()))}
+	_, err := run(env, "zypper", append([]string{"--non-interactive", "--quiet"}, args...)...)
+	return err
+}
+
+// PendingUpdates parses the list-updates table
+// e.g v | Main Update Repository | openssl-3 | 3.1.4-9.1 | 3.1.4-9.2 | x86_64
+func (pm *Zypper) PendingUpdates() ([]PendingUpdate, error) {
+	updates := []PendingUpdate{}
+
+	if _, err := runAllowingExitCodes(zypperInfoExitCodes, "zypper", "--non-interactive", "--quiet", "refresh"); err != nil {
+		return nil, err
+	}
+
+	out, err := runAllowingExitCodes(zypperInfoExitCodes, "zypper", "--non-interactive", "--quiet", "list-updates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, columns := range zypperTableRows(out) {
+		if len(columns) < 6 {
+			continue
+		}
+		updates = append(updates, PendingUpdate{
+			Name:           columns[2],
+			CurrentVersion: columns[3],
+			NewVersion:     columns[4],
+			Source:         columns[1],
+		})
+	}
+
+	// Leap and SLE ship security fixes as patches that group several packages, they're reported
+	// as updates too. Tumbleweed has no patches so the table is empty
+	out, err = runAllowingExitCodes(zypperInfoExitCodes, "zypper", "--non-interactive", "--quiet", "list-patches", "--category", "security")
+	if err != nil {
+		return updates, nil
+	}
+
+	// e.g Update Repository | openSUSE-SLE-15.6-2024-1234 | security | important | --- | needed | ...
+	for _, columns := range zypperTableRows(out) {
+		if len(columns) < 3 || columns[2] != "security" {
+			continue
+		}
+		update := PendingUpdate{
+			Name:       columns[1],
+			Source:     columns[0],
+			Security:   true,
+			Advisories: []string{columns[1]},
+			Severity:   SEVERITY_UNKNOWN,
+		}
+		if len(columns) > 3 {
+			update.Severity = NormalizeSeverity(columns[3])
+		}
+		updates = append(updates, update)
+	}
+
+	return updates, nil
+}
+
+// zypperTableRows splits the rows of a zypper table skipping the header and separator lines
+func zypperTableRows(out []byte) [][]string {
+	rows := [][]string{}
+	header := true
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		if !strings.Contains(line, "|") {
+			continue
+		}
+		if strings.HasPrefix(line, "--") || strings.Contains(line, "-+-") {
+			header = false
+			continue
+		}
+		if header {
+			continue
+		}
+
+		columns := strings.Split(line, "|")
+		for i := range columns {
+			columns[i] = strings.TrimSpace(columns[i])
+		}
+		rows = append(rows, columns)
+	}
+
+	return rows
+}
@@ -0,0 +1,255 @@
+//go:build linux
+
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
+	"github.com/scncore/scnorion-agent/internal/commands/power"
+)
+
+const PATCH_OUTPUT_MAX_LINES = 50
+
+// zypper exit codes over 100 give information, only some of them mean that the command succeeded
+const (
+	ZYPPER_EXIT_INF_UPDATE_NEEDED  = 100
+	ZYPPER_EXIT_INF_REBOOT_NEEDED  = 102
+	ZYPPER_EXIT_INF_RESTART_NEEDED = 103
+	ZYPPER_EXIT_INF_CAP_NOT_FOUND  = 104
+	ZYPPER_EXIT_INF_REPOS_SKIPPED  = 106
+)
+
+// zypper asks to run the command again after it updates itself
+const ZYPPER_MAX_RESTARTS = 3
+
+// Keep the current configuration files if a package ships new ones and wait for the dpkg lock
+var aptConfOptions = []string{"-o", "Dpkg::Options::=--force-confdef", "-o", "Dpkg::Options::=--force-confold", "-o", "DPkg::Lock::Timeout=300"}
+
+func Supported() bool {
+	return true
+}
+
+func Run(req PatchRequest) (*PatchResult, error) {
+	if !running.CompareAndSwap(false, true) {
+		return nil, errors.New("a patch installation is already running")
+	}
+	defer running.Store(false)
+
+	result := &PatchResult{SecurityOnly: req.SecurityOnly, StartedAt: time.Now(), Packages: []PackageResult{}}
+
+	pm, err := packagemanager.System()
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := pm.PendingUpdates()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the pending updates, reason: %v", err)
+	}
+
+	selected := []packagemanager.PendingUpdate{}
+	for _, u := range before {
+		if !req.SecurityOnly || u.Security {
+			selected = append(selected, u)
+		}
+	}
+
+	var upgradeErr error
+	if len(selected) > 0 {
+		log.Printf("[INFO]: %d updates are going to be installed using %s", len(selected), pm.Name())
+
+		var download, upgrade [][]string
+		switch pm.Name() {
+		case "apt":
+			download, upgrade = aptCommands(selected, req.SecurityOnly)
+		case "dnf":
+			download, upgrade = dnfCommands(req.SecurityOnly)
+		case "zypper":
+			download, upgrade = zypperCommands(req.SecurityOnly)
+		default:
+			return nil, fmt.Errorf("patch installation is not supported with %s", pm.Name())
+		}
+
+		// Packages are downloaded first so the system is changed only if all of them are available
+		for _, cmd := range download {
+			if out, err := runCommand(cmd); err != nil {
+				result.Output = out
+				return result, fmt.Errorf("could not download the updates, reason: %v", err)
+			}
+		}
+
+		for _, cmd := range upgrade {
+			out, err := runCommand(cmd)
+			result.Output += out + "\n"
+			if err != nil {
+				upgradeErr = err
+				result.Error = err.Error()
+				log.Printf("[ERROR]: patch installation finished with errors, reason: %v", err)
+			}
+		}
+	}
+
+	// Every selected update that is no longer pending has been installed
+	after, err := pm.PendingUpdates()
+	if err != nil {
+		log.Printf("[ERROR]: could not check the pending updates after the installation, reason: %v", err)
+	}
+	stillPending := map[string]bool{}
+	for _, u := range after {
+		stillPending[u.Name] = true
+	}
+
+	for _, u := range selected {
+		p := PackageResult{
+			Name:        u.Name,
+			FromVersion: u.CurrentVersion,
+			ToVersion:   u.NewVersion,
+			Security:    u.Security,
+			Installed:   err == nil && !stillPending[u.Name],
+		}
+		if !p.Installed && upgradeErr != nil {
+			p.Error = upgradeErr.Error()
+		}
+		result.Packages = append(result.Packages, p)
+	}
+
+	result.RebootRequired = IsRebootRequired(pm.Name())
+	if result.RebootRequired && req.Reboot {
+		if err := scheduleReboot(req.RebootDeadline); err != nil {
+			log.Printf("[ERROR]: could not schedule the reboot after patching, reason: %v", err)
+		} else {
+			result.RebootScheduled = true
+		}
+	}
+
+	result.FinishedAt = time.Now()
+	return result, nil
+}
+
+func aptCommands(selected []packagemanager.PendingUpdate, securityOnly bool) ([][]string, [][]string) {
+	if !securityOnly {
+		download := append([]string{"apt-get", "dist-upgrade", "--yes", "--download-only"}, aptConfOptions...)
+		upgrade := append([]string{"apt-get", "dist-upgrade", "--yes"}, aptConfOptions...)
+		return [][]string{download}, [][]string{upgrade}
+	}
+
+	// apt has no security filter so we upgrade only the packages from the security pocket
+	names := []string{}
+	for _, u := range selected {
+		names = append(names, u.Name)
+	}
+	download := append(append([]string{"apt-get", "install", "--only-upgrade", "--yes", "--download-only"}, aptConfOptions...), names...)
+	upgrade := append(append([]string{"apt-get", "install", "--only-upgrade", "--yes"}, aptConfOptions...), names...)
+	return [][]string{download}, [][]string{upgrade}
+}
+
+func dnfCommands(securityOnly bool) ([][]string, [][]string) {
+	download := []string{"dnf", "upgrade", "--assumeyes", "--downloadonly"}
+	upgrade := []string{"dnf", "upgrade", "--assumeyes"}
+	if securityOnly {
+		download = append(download, "--security")
+		upgrade = append(upgrade, "--security")
+	}
+	return [][]string{download}, [][]string{upgrade}
+}
+
+func zypperCommands(securityOnly bool) ([][]string, [][]string) {
+	if securityOnly {
+		return [][]string{{"zypper", "--non-interactive", "patch", "--category", "security", "--download-only"}},
+			[][]string{{"zypper", "--non-interactive", "patch", "--category", "security", "--auto-agree-with-licenses"}}
+	}
+	return [][]string{{"zypper", "--non-interactive", "update", "--download-only"}},
+		[][]string{{"zypper", "--non-interactive", "update", "--auto-agree-with-licenses"}}
+}
+
+func runCommand(args []string) (string, error) {
+	output := ""
+	for restarts := 0; ; restarts++ {
+		cmd := exec.Command(args[0], args[1:]...)
+		switch args[0] {
+		case "apt-get":
+			cmd.Env = append(os.Environ(), packagemanager.AptEnv...)
+		case "zypper":
+			cmd.Env = append(os.Environ(), "ZYPP_LOCK_TIMEOUT=300")
+		}
+
+		out, err := cmd.CombinedOutput()
+		output += string(out)
+
+		if exitErr, ok := err.(*exec.ExitError); ok && args[0] == "zypper" {
+			switch exitErr.ExitCode() {
+			case ZYPPER_EXIT_INF_UPDATE_NEEDED, ZYPPER_EXIT_INF_REBOOT_NEEDED, ZYPPER_EXIT_INF_REPOS_SKIPPED:
+				err = nil
+			case ZYPPER_EXIT_INF_RESTART_NEEDED:
+				// zypper has updated itself, the remaining patches are installed by the next run
+				if restarts < ZYPPER_MAX_RESTARTS {
+					log.Println("[INFO]: zypper has been updated, running the command again")
+					continue
+				}
+				err = errors.New("zypper still asks to run the command again after updating itself")
+			case ZYPPER_EXIT_INF_CAP_NOT_FOUND:
+				err = errors.New("zypper could not find some of the requested packages")
+			}
+		}
+
+		return packagemanager.LastLines(output, PATCH_OUTPUT_MAX_LINES), err
+	}
+}
+
+// IsRebootRequired checks the flag used by each family after the updates
+func IsRebootRequired(manager string) bool {
+	switch manager {
+	case "apt":
+		_, err := os.Stat("/var/run/reboot-required")
+		return err == nil
+	case "dnf":
+		// needs-restarting -r exits with 1 when a reboot is needed
+		err := exec.Command("needs-restarting", "-r").Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode() == 1
+		}
+		if err != nil {
+			// dnf5 moved it to a dnf subcommand
+			err = exec.Command("dnf", "needs-restarting", "-r").Run()
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return exitErr.ExitCode() == 1
+			}
+		}
+		return false
+	case "zypper":
+		// zypper needs-rebooting exits with 102 when a reboot is needed
+		err := exec.Command("zypper", "needs-rebooting").Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode() == 102
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// scheduleReboot warns the user and lets them postpone the reboot as long as the deadline is not reached
+func scheduleReboot(deadline time.Time) error {
+	action := power.PowerAction{
+		NotifyUser:      true,
+		Countdown:       power.DEFAULT_COUNTDOWN_MINUTES,
+		PostponeMinutes: power.DEFAULT_POSTPONE_MINUTES,
+	}
+	action.Date = time.Now()
+
+	if !deadline.IsZero() {
+		available := time.Until(deadline) - time.Duration(action.Countdown)*time.Minute
+		if postpones := int(available / (time.Duration(action.PostponeMinutes) * time.Minute)); postpones > 0 {
+			action.AllowPostpone = true
+			action.MaxPostpones = postpones
+		}
+	}
+
+	return power.Reboot(action)
+}
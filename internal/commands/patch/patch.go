@@ -0,0 +1,95 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	FREQUENCY_DAILY  = "daily"
+	FREQUENCY_WEEKLY = "weekly"
+)
+
+type PatchRequest struct {
+	SecurityOnly bool `json:"security_only"`
+	// Reboot if the updates require it, users are warned and can postpone it until the deadline
+	Reboot         bool      `json:"reboot"`
+	RebootDeadline time.Time `json:"reboot_deadline,omitempty"`
+}
+
+// Schedule is received with the remote config, times are HH:MM in the agent's local time
+type Schedule struct {
+	Enabled        bool   `json:"enabled"`
+	Frequency      string `json:"frequency"`
+	Weekday        int    `json:"weekday"`
+	Time           string `json:"time"`
+	SecurityOnly   bool   `json:"security_only"`
+	Reboot         bool   `json:"reboot"`
+	RebootDeadline string `json:"reboot_deadline,omitempty"`
+}
+
+type PackageResult struct {
+	Name        string `json:"name"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+	Security    bool   `json:"security"`
+	Installed   bool   `json:"installed"`
+	Error       string `json:"error,omitempty"`
+}
+
+type PatchResult struct {
+	AgentID         string          `json:"agent_id"`
+	SecurityOnly    bool            `json:"security_only"`
+	Scheduled       bool            `json:"scheduled"`
+	Packages        []PackageResult `json:"packages"`
+	RebootRequired  bool            `json:"reboot_required"`
+	RebootScheduled bool            `json:"reboot_scheduled"`
+	StartedAt       time.Time       `json:"started_at"`
+	FinishedAt      time.Time       `json:"finished_at"`
+	Output          string          `json:"output,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// Only one patch run at a time, package managers would fight for the lock anyway
+var running atomic.Bool
+
+// ParseClock parses an HH:MM time
+func ParseClock(clock string) (int, int, error) {
+	h, m, found := strings.Cut(clock, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("time %s is not in HH:MM format", clock)
+	}
+
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("hour in %s is not valid", clock)
+	}
+
+	minute, err := strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("minute in %s is not valid", clock)
+	}
+
+	return hour, minute, nil
+}
+
+// Request builds the request for a scheduled run, the deadline is the next time
+// the reboot deadline clock is reached from now
+func (s *Schedule) Request(now time.Time) PatchRequest {
+	req := PatchRequest{SecurityOnly: s.SecurityOnly, Reboot: s.Reboot}
+
+	if s.Reboot && s.RebootDeadline != "" {
+		if hour, minute, err := ParseClock(s.RebootDeadline); err == nil {
+			deadline := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+			if !deadline.After(now) {
+				deadline = deadline.AddDate(0, 0, 1)
+			}
+			req.RebootDeadline = deadline
+		}
+	}
+
+	return req
+}
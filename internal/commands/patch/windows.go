@@ -0,0 +1,13 @@
+//go:build windows
+
+package patch
+
+import "errors"
+
+func Supported() bool {
+	return false
+}
+
+func Run(req PatchRequest) (*PatchResult, error) {
+	return nil, errors.New("patch installation is not supported on this platform, use the system updates settings")
+}
@@ -0,0 +1,92 @@
+//go:build darwin
+
+package power
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+
+	"github.com/scncore/scnorion-agent/internal/commands/runtime"
+)
+
+const CGSESSION_PATH = "/System/Library/CoreServices/Menu Extras/User.menu/Contents/Resources/CGSession"
+
+// The user can't be warned before a reboot or power off, the countdown and postpone options are ignored
+const NOTIFY_USER_SUPPORTED = false
+
+func CancelShutdown() error {
+	clearPendingAction()
+
+	if err := exec.Command("killall", "shutdown").Run(); err != nil {
+		log.Printf("[ERROR]: could not cancel scheduled shutdown, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: scheduled shutdown has been cancelled")
+	return nil
+}
+
+func Logoff() error {
+	username, err := runtime.GetLoggedInUser()
+	if err != nil {
+		return err
+	}
+
+	if username == "" {
+		return errors.New("there is no user logged in")
+	}
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+
+	if err := exec.Command("launchctl", "bootout", "gui/"+u.Uid).Run(); err != nil {
+		log.Printf("[ERROR]: could not log off user %s, reason: %v", username, err)
+		return err
+	}
+
+	log.Printf("[INFO]: user %s has been logged off", username)
+	return nil
+}
+
+// Lock uses CGSession to go back to the login window, it was removed in recent macOS
+// versions where the lock screen shortcut (Ctrl+Cmd+Q) is sent instead
+func Lock() error {
+	username, err := runtime.GetLoggedInUser()
+	if err != nil {
+		return err
+	}
+
+	if username == "" || username == "root" {
+		return errors.New("there is no user logged in")
+	}
+
+	if _, err := os.Stat(CGSESSION_PATH); err == nil {
+		err = runtime.RunAsUser(username, CGSESSION_PATH, []string{"-suspend"}, true)
+	} else {
+		err = runtime.RunAsUser(username, "osascript", []string{"-e", `tell application "System Events" to keystroke "q" using {control down, command down}`}, true)
+	}
+	if err != nil {
+		log.Printf("[ERROR]: could not lock the screen of user %s, reason: %v", username, err)
+		return err
+	}
+
+	log.Printf("[INFO]: screen of user %s has been locked", username)
+	return nil
+}
+
+func Suspend() error {
+	if err := exec.Command("pmset", "sleepnow").Run(); err != nil {
+		log.Printf("[ERROR]: could not suspend the computer, reason: %v", err)
+		return err
+	}
+	return nil
+}
+
+func Hibernate() error {
+	return errors.New("hibernate is not supported by the macOS agent")
+}
@@ -0,0 +1,191 @@
+//go:build linux
+
+package power
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/scncore/scnorion-agent/internal/commands/runtime"
+)
+
+// The user is warned with a countdown and can postpone the action
+const NOTIFY_USER_SUPPORTED = true
+
+func Reboot(action PowerAction) error {
+	return schedule("-r", "restart", action)
+}
+
+func PowerOff(action PowerAction) error {
+	return schedule("-P", "shut down", action)
+}
+
+func CancelShutdown() error {
+	clearPendingAction()
+
+	if err := exec.Command("shutdown", "-c").Run(); err != nil {
+		log.Printf("[ERROR]: could not cancel scheduled shutdown, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: scheduled shutdown has been cancelled")
+	return nil
+}
+
+func Logoff() error {
+	username, err := runtime.GetLoggedInUser()
+	if err != nil {
+		return err
+	}
+
+	if username == "" {
+		return errors.New("there is no user logged in")
+	}
+
+	if err := exec.Command("loginctl", "terminate-user", username).Run(); err != nil {
+		log.Printf("[ERROR]: could not log off user %s, reason: %v", username, err)
+		return err
+	}
+
+	log.Printf("[INFO]: user %s has been logged off", username)
+	return nil
+}
+
+func Lock() error {
+	if err := exec.Command("loginctl", "lock-sessions").Run(); err != nil {
+		log.Printf("[ERROR]: could not lock sessions, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: sessions have been locked")
+	return nil
+}
+
+func Suspend() error {
+	if err := exec.Command("systemctl", "suspend").Run(); err != nil {
+		log.Printf("[ERROR]: could not suspend the computer, reason: %v", err)
+		return err
+	}
+	return nil
+}
+
+func Hibernate() error {
+	if err := exec.Command("systemctl", "hibernate").Run(); err != nil {
+		log.Printf("[ERROR]: could not hibernate the computer, reason: %v", err)
+		return err
+	}
+	return nil
+}
+
+func schedule(flag, verb string, action PowerAction) error {
+	ctx := newPendingAction()
+
+	when := int(time.Until(action.Date).Minutes())
+
+	username := ""
+	if action.NotifyUser {
+		u, err := runtime.GetLoggedInUser()
+		if err != nil {
+			log.Printf("[ERROR]: could not get the logged in user to notify the %s, reason: %v", verb, err)
+		}
+		username = u
+	}
+
+	// Nobody to warn so the action is scheduled as requested
+	if username == "" {
+		return shutdown(flag, when)
+	}
+
+	// The user must have the countdown time at least to save their work
+	if when < action.countdownMinutes() {
+		when = action.countdownMinutes()
+	}
+
+	if err := shutdown(flag, when); err != nil {
+		return err
+	}
+
+	go notifyCountdown(ctx, username, flag, verb, time.Now().Add(time.Duration(when)*time.Minute), action)
+	return nil
+}
+
+func shutdown(flag string, when int) error {
+	if when > 0 {
+		if err := exec.Command("shutdown", flag, "+"+strconv.Itoa(when)).Run(); err != nil {
+			log.Printf("[ERROR]: could not schedule shutdown, reason: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	if err := exec.Command("shutdown", flag, "now").Run(); err != nil {
+		log.Printf("[ERROR]: could not initiate shutdown, reason: %v", err)
+		return err
+	}
+	return nil
+}
+
+func notifyCountdown(ctx context.Context, username, flag, verb string, deadline time.Time, action PowerAction) {
+	countdown := time.Duration(action.countdownMinutes()) * time.Minute
+
+	for {
+		// Wait until the countdown must be shown
+		if wait := time.Until(deadline) - countdown; wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		postpone := askUser(username, verb, deadline, action.canPostpone(currentPostpones()))
+
+		// The action may have been cancelled while the dialog was open
+		if ctx.Err() != nil || !postpone {
+			return
+		}
+
+		n := addPostpone()
+		log.Printf("[INFO]: user %s has postponed the %s (%d of %d)", username, verb, n, action.MaxPostpones)
+
+		if err := exec.Command("shutdown", "-c").Run(); err != nil {
+			log.Printf("[ERROR]: could not cancel shutdown to postpone it, reason: %v", err)
+			return
+		}
+
+		if err := shutdown(flag, action.postponeMinutes()); err != nil {
+			return
+		}
+		deadline = time.Now().Add(time.Duration(action.postponeMinutes()) * time.Minute)
+	}
+}
+
+// askUser shows the countdown dialog and returns true if the user chose to postpone
+func askUser(username, verb string, deadline time.Time, canPostpone bool) bool {
+	minutes := int(time.Until(deadline).Minutes())
+	seconds := int(time.Until(deadline).Seconds())
+	if seconds <= 0 {
+		return false
+	}
+
+	text := fmt.Sprintf("Your computer will %s in %d minutes as requested by your administrator. Please save your work.", verb, minutes)
+
+	args := []string{"--warning", "--title", "scnorion", "--text", text, "--width", "400", "--timeout", strconv.Itoa(seconds)}
+	if canPostpone {
+		args = []string{"--question", "--title", "scnorion", "--text", text, "--ok-label", "OK", "--cancel-label", "Postpone", "--width", "400", "--timeout", strconv.Itoa(seconds)}
+	}
+
+	err := runtime.RunAsUser(username, "zenity", args, true)
+
+	// zenity exits with 1 when the cancel button, our postpone button, is clicked
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return canPostpone && exitErr.ExitCode() == 1
+	}
+	return false
+}
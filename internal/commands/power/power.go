@@ -0,0 +1,90 @@
+package power
+
+import (
+	"context"
+	"sync"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+const (
+	DEFAULT_COUNTDOWN_MINUTES = 5
+	DEFAULT_POSTPONE_MINUTES  = 60
+)
+
+// PowerAction extends the console reboot/power off request with the options
+// used to warn the logged in user before the action takes place
+type PowerAction struct {
+	scnorion_nats.RebootOrRestart
+	NotifyUser      bool `json:"notify_user"`
+	Countdown       int  `json:"countdown"`
+	AllowPostpone   bool `json:"allow_postpone"`
+	MaxPostpones    int  `json:"max_postpones"`
+	PostponeMinutes int  `json:"postpone_minutes"`
+}
+
+// pending keeps track of the scheduled action so it can be cancelled
+// and so we know how many times the user has postponed it
+var pending = struct {
+	sync.Mutex
+	cancel    context.CancelFunc
+	postpones int
+}{}
+
+func (a *PowerAction) countdownMinutes() int {
+	if a.Countdown <= 0 {
+		return DEFAULT_COUNTDOWN_MINUTES
+	}
+	return a.Countdown
+}
+
+func (a *PowerAction) postponeMinutes() int {
+	if a.PostponeMinutes <= 0 {
+		return DEFAULT_POSTPONE_MINUTES
+	}
+	return a.PostponeMinutes
+}
+
+func (a *PowerAction) canPostpone(postpones int) bool {
+	return a.AllowPostpone && postpones < a.MaxPostpones
+}
+
+func newPendingAction() context.Context {
+	pending.Lock()
+	defer pending.Unlock()
+
+	if pending.cancel != nil {
+		pending.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pending.cancel = cancel
+	pending.postpones = 0
+	return ctx
+}
+
+func clearPendingAction() {
+	pending.Lock()
+	defer pending.Unlock()
+
+	if pending.cancel != nil {
+		pending.cancel()
+		pending.cancel = nil
+	}
+	pending.postpones = 0
+}
+
+func addPostpone() int {
+	pending.Lock()
+	defer pending.Unlock()
+
+	pending.postpones++
+	return pending.postpones
+}
+
+func currentPostpones() int {
+	pending.Lock()
+	defer pending.Unlock()
+
+	return pending.postpones
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package power
+
+import (
+	"log"
+	"os/exec"
+
+	"github.com/scncore/scnorion-agent/internal/commands/runtime"
+)
+
+// The user can't be warned before a reboot or power off, the countdown and postpone options are ignored
+const NOTIFY_USER_SUPPORTED = false
+
+func CancelShutdown() error {
+	clearPendingAction()
+
+	if err := exec.Command("cmd", "/C", "shutdown", "/a").Run(); err != nil {
+		log.Printf("[ERROR]: could not cancel scheduled shutdown, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: scheduled shutdown has been cancelled")
+	return nil
+}
+
+func Logoff() error {
+	// shutdown /l logs off the session that runs it so it must be run as the user
+	if err := runtime.RunAsUser("shutdown.exe", []string{"/l"}); err != nil {
+		log.Printf("[ERROR]: could not log off user, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: user has been logged off")
+	return nil
+}
+
+func Lock() error {
+	if err := runtime.RunAsUser("rundll32.exe", []string{"user32.dll,LockWorkStation"}); err != nil {
+		log.Printf("[ERROR]: could not lock workstation, reason: %v", err)
+		return err
+	}
+
+	log.Println("[INFO]: workstation has been locked")
+	return nil
+}
+
+func Suspend() error {
+	if err := exec.Command("rundll32.exe", "powrprof.dll,SetSuspendState", "0,1,0").Run(); err != nil {
+		log.Printf("[ERROR]: could not suspend the computer, reason: %v", err)
+		return err
+	}
+	return nil
+}
+
+func Hibernate() error {
+	if err := exec.Command("cmd", "/C", "shutdown", "/h").Run(); err != nil {
+		log.Printf("[ERROR]: could not hibernate the computer, reason: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build darwin
+
+package processes
+
+import "syscall"
+
+// renice sets the nice value, from -20 (highest priority) to 19
+func renice(pid int32, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, int(pid), nice)
+}
@@ -0,0 +1,314 @@
+package processes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// CPU usage is measured during this time so it shows what the process is doing now
+// and not the average since it started
+const CPU_SAMPLE_DURATION = time.Second
+
+const (
+	SORT_BY_CPU    = "cpu"
+	SORT_BY_MEMORY = "memory"
+	SORT_BY_PID    = "pid"
+	SORT_BY_NAME   = "name"
+	SORT_BY_START  = "start"
+)
+
+const (
+	ACTION_TERMINATE = "terminate"
+	ACTION_KILL      = "kill"
+	ACTION_RENICE    = "renice"
+)
+
+// Processes that can't be killed or reniced remotely, the agent itself is always protected
+var protectedProcesses = []string{
+	"sshd", "systemd", "init", "launchd", "kernel_task", "WindowServer", "loginwindow",
+	"System", "Registry", "smss.exe", "csrss.exe", "wininit.exe", "winlogon.exe", "services.exe", "lsass.exe",
+	"scnorion-agent", "scnorion-agent.exe", "scnorion-agent-service", "scnorion-agent-service.exe",
+}
+
+type ProcessListRequest struct {
+	SortBy      string  `json:"sort_by,omitempty"`
+	Ascending   bool    `json:"ascending,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	User        string  `json:"user,omitempty"`
+	MinCPU      float64 `json:"min_cpu,omitempty"`
+	Limit       int     `json:"limit,omitempty"`
+	Connections bool    `json:"connections,omitempty"`
+}
+
+type Connection struct {
+	Protocol      string `json:"protocol"`
+	LocalAddress  string `json:"local_address"`
+	LocalPort     uint32 `json:"local_port"`
+	RemoteAddress string `json:"remote_address,omitempty"`
+	RemotePort    uint32 `json:"remote_port,omitempty"`
+	Status        string `json:"status,omitempty"`
+}
+
+type Process struct {
+	PID         int32        `json:"pid"`
+	PPID        int32        `json:"ppid"`
+	Name        string       `json:"name"`
+	User        string       `json:"user,omitempty"`
+	CommandLine string       `json:"command_line,omitempty"`
+	CPU         float64      `json:"cpu"`
+	RSS         uint64       `json:"rss"`
+	StartTime   time.Time    `json:"start_time"`
+	Connections []Connection `json:"connections,omitempty"`
+}
+
+type ProcessListResult struct {
+	Processes []Process `json:"processes"`
+	Total     int       `json:"total"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ProcessControlRequest targets a process by PID or every process with that name
+type ProcessControlRequest struct {
+	Action string `json:"action"`
+	PID    int32  `json:"pid,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Nice   int    `json:"nice,omitempty"`
+}
+
+type ProcessActionResult struct {
+	PID   int32  `json:"pid"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+type ProcessControlResult struct {
+	Processes []ProcessActionResult `json:"processes"`
+	Error     string                `json:"error,omitempty"`
+}
+
+func List(req ProcessListRequest) (*ProcessListResult, error) {
+	result := ProcessListResult{Processes: []Process{}}
+
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Take the CPU times twice, processes that end in between are skipped
+	before := map[int32]float64{}
+	for _, p := range procs {
+		if t, err := p.Times(); err == nil {
+			before[p.Pid] = t.User + t.System
+		}
+	}
+	start := time.Now()
+	time.Sleep(CPU_SAMPLE_DURATION)
+	elapsed := time.Since(start).Seconds()
+
+	connections := map[int32][]Connection{}
+	if req.Connections {
+		connections = getConnections()
+	}
+
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		if req.Name != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(req.Name)) {
+			continue
+		}
+
+		proc := Process{PID: p.Pid, Name: name}
+		proc.User, _ = p.Username()
+		if req.User != "" && !strings.EqualFold(proc.User, req.User) {
+			continue
+		}
+
+		if t, err := p.Times(); err == nil {
+			if previous, ok := before[p.Pid]; ok && elapsed > 0 {
+				proc.CPU = (t.User + t.System - previous) / elapsed * 100
+			}
+		}
+		if proc.CPU < req.MinCPU {
+			continue
+		}
+
+		proc.PPID, _ = p.Ppid()
+		proc.CommandLine, _ = p.Cmdline()
+		if m, err := p.MemoryInfo(); err == nil {
+			proc.RSS = m.RSS
+		}
+		if created, err := p.CreateTime(); err == nil {
+			proc.StartTime = time.UnixMilli(created)
+		}
+		proc.Connections = connections[p.Pid]
+
+		result.Processes = append(result.Processes, proc)
+	}
+
+	sortProcesses(result.Processes, req.SortBy, req.Ascending)
+
+	result.Total = len(result.Processes)
+	if req.Limit > 0 && len(result.Processes) > req.Limit {
+		result.Processes = result.Processes[:req.Limit]
+	}
+
+	return &result, nil
+}
+
+func sortProcesses(procs []Process, sortBy string, ascending bool) {
+	slices.SortFunc(procs, func(a, b Process) int {
+		var c int
+		switch sortBy {
+		case SORT_BY_MEMORY:
+			c = cmp.Compare(a.RSS, b.RSS)
+		case SORT_BY_PID:
+			c = cmp.Compare(a.PID, b.PID)
+		case SORT_BY_NAME:
+			c = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case SORT_BY_START:
+			c = a.StartTime.Compare(b.StartTime)
+		default:
+			c = cmp.Compare(a.CPU, b.CPU)
+		}
+		if !ascending {
+			c = -c
+		}
+		return c
+	})
+}
+
+// getConnections reads the sockets once and groups them by process
+func getConnections() map[int32][]Connection {
+	connections := map[int32][]Connection{}
+
+	stats, err := net.Connections("inet")
+	if err != nil {
+		return connections
+	}
+
+	for _, s := range stats {
+		if s.Pid == 0 {
+			continue
+		}
+		protocol := "tcp"
+		if s.Type == 2 {
+			protocol = "udp"
+		}
+		connections[s.Pid] = append(connections[s.Pid], Connection{
+			Protocol:      protocol,
+			LocalAddress:  s.Laddr.IP,
+			LocalPort:     s.Laddr.Port,
+			RemoteAddress: s.Raddr.IP,
+			RemotePort:    s.Raddr.Port,
+			Status:        s.Status,
+		})
+	}
+
+	return connections
+}
+
+// Control terminates, kills or renices the processes matching the request
+func Control(req ProcessControlRequest) (*ProcessControlResult, error) {
+	result := ProcessControlResult{Processes: []ProcessActionResult{}}
+
+	if req.PID == 0 && req.Name == "" {
+		return nil, errors.New("a PID or a process name is required")
+	}
+
+	if req.Action != ACTION_TERMINATE && req.Action != ACTION_KILL && req.Action != ACTION_RENICE {
+		return nil, fmt.Errorf("action %s is not valid", req.Action)
+	}
+
+	targets := []*process.Process{}
+	if req.PID != 0 {
+		p, err := process.NewProcess(req.PID)
+		if err != nil {
+			return nil, fmt.Errorf("process %d was not found", req.PID)
+		}
+		targets = append(targets, p)
+	} else {
+		procs, err := process.Processes()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range procs {
+			if name, err := p.Name(); err == nil && name == req.Name {
+				targets = append(targets, p)
+			}
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("no process named %s was found", req.Name)
+		}
+	}
+
+	for _, p := range targets {
+		name, _ := p.Name()
+		r := ProcessActionResult{PID: p.Pid, Name: name}
+
+		if err := checkProtected(p, name); err != nil {
+			r.Error = err.Error()
+			result.Processes = append(result.Processes, r)
+			continue
+		}
+
+		var err error
+		switch req.Action {
+		case ACTION_TERMINATE:
+			err = p.Terminate()
+		case ACTION_KILL:
+			err = p.Kill()
+		case ACTION_RENICE:
+			err = renice(p.Pid, req.Nice)
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+
+		result.Processes = append(result.Processes, r)
+	}
+
+	return &result, nil
+}
+
+// checkProtected refuses to act on the system processes, the agent and its ancestors
+func checkProtected(p *process.Process, name string) error {
+	if p.Pid <= 1 {
+		return fmt.Errorf("process %d is protected", p.Pid)
+	}
+
+	if slices.ContainsFunc(protectedProcesses, func(protected string) bool { return strings.EqualFold(protected, name) }) {
+		return fmt.Errorf("process %s is protected", name)
+	}
+
+	if ex, err := os.Executable(); err == nil {
+		if exe, err := p.Exe(); err == nil && filepath.Clean(exe) == filepath.Clean(ex) {
+			return fmt.Errorf("process %s is the agent and it's protected", name)
+		}
+	}
+
+	for pid := int32(os.Getpid()); pid > 1; {
+		if pid == p.Pid {
+			return fmt.Errorf("process %s is the agent or its parent and it's protected", name)
+		}
+		parent, err := process.NewProcess(pid)
+		if err != nil {
+			break
+		}
+		if pid, err = parent.Ppid(); err != nil {
+			break
+		}
+	}
+
+	return nil
+}
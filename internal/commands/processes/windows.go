@@ -0,0 +1,32 @@
+//go:build windows
+
+package processes
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// renice maps the nice value to the closest priority class
+func renice(pid int32, nice int) error {
+	h, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+
+	var priorityClass uint32
+	switch {
+	case nice <= -15:
+		priorityClass = windows.HIGH_PRIORITY_CLASS
+	case nice < 0:
+		priorityClass = windows.ABOVE_NORMAL_PRIORITY_CLASS
+	case nice == 0:
+		priorityClass = windows.NORMAL_PRIORITY_CLASS
+	case nice < 15:
+		priorityClass = windows.BELOW_NORMAL_PRIORITY_CLASS
+	default:
+		priorityClass = windows.IDLE_PRIORITY_CLASS
+	}
+
+	return windows.SetPriorityClass(h, priorityClass)
+}
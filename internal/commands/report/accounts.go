@@ -0,0 +1,75 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	PASSWORD_SET    = "set"
+	PASSWORD_LOCKED = "locked"
+	PASSWORD_EMPTY  = "empty"
+	PASSWORD_NONE   = "none"
+	// The shadow file could not be read
+	PASSWORD_UNKNOWN = "unknown"
+)
+
+// LocalUser has the account metadata used for access reviews, password hashes are never read
+type LocalUser struct {
+	Name                string     `json:"name"`
+	UID                 int        `json:"uid"`
+	GID                 int        `json:"gid"`
+	Comment             string     `json:"comment,omitempty"`
+	Home                string     `json:"home"`
+	Shell               string     `json:"shell"`
+	System              bool       `json:"system"`
+	CanLogin            bool       `json:"can_login"`
+	Password            string     `json:"password"`
+	PasswordLastChanged *time.Time `json:"password_last_changed,omitempty"`
+	PasswordMaxDays     int        `json:"password_max_days,omitempty"`
+	PasswordExpires     *time.Time `json:"password_expires,omitempty"`
+	AccountExpires      *time.Time `json:"account_expires,omitempty"`
+	Groups              []string   `json:"groups"`
+	Administrator       bool       `json:"administrator"`
+	LastLogin           *time.Time `json:"last_login,omitempty"`
+	LastLoginFrom       string     `json:"last_login_from,omitempty"`
+}
+
+type LocalGroup struct {
+	Name    string   `json:"name"`
+	GID     int      `json:"gid"`
+	Members []string `json:"members"`
+}
+
+type SudoRule struct {
+	File string `json:"file"`
+	Rule string `json:"rule"`
+}
+
+type Accounts struct {
+	Users          []LocalUser  `json:"users"`
+	Groups         []LocalGroup `json:"groups"`
+	RootAccounts   []string     `json:"root_accounts"`
+	Administrators []string     `json:"administrators"`
+	SudoRules      []SudoRule   `json:"sudo_rules,omitempty"`
+}
+
+func (r *Report) logAccounts() {
+	if r.Accounts == nil {
+		return
+	}
+
+	fmt.Printf("\n** 👥 Local Accounts ************************************************************************************************\n")
+	for _, u := range r.Accounts.Users {
+		if u.System && !u.Administrator {
+			continue
+		}
+		lastLogin := "Never"
+		if u.LastLogin != nil {
+			lastLogin = u.LastLogin.Format(time.DateTime)
+		}
+		fmt.Printf("%-40s |  uid %d, password %s, last login %s \n", u.Name, u.UID, u.Password, lastLogin)
+	}
+	fmt.Printf("%-40s |  %v \n", "Root Accounts", r.Accounts.RootAccounts)
+	fmt.Printf("%-40s |  %v \n", "Administrators", r.Accounts.Administrators)
+}
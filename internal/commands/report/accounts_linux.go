@@ -0,0 +1,482 @@
+//go:build linux
+
+package report
+
+import (
+	"bufio"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	PASSWD_PATH    = "/etc/passwd"
+	SHADOW_PATH    = "/etc/shadow"
+	GROUP_PATH     = "/etc/group"
+	SUDOERS_PATH   = "/etc/sudoers"
+	LOGIN_DEFS     = "/etc/login.defs"
+	LASTLOG_PATH   = "/var/log/lastlog"
+	DEFAULT_UIDMIN = 1000
+)
+
+// nobody is above UID_MIN but it's a system account
+const NOBODY_UID = 65534
+
+// lastlog record: ll_time (int32), ll_line[32], ll_host[256]
+const LASTLOG_RECORD_SIZE = 292
+
+// Members of these groups can become root with the default sudoers or polkit rules
+var adminGroups = []string{"sudo", "wheel", "admin"}
+
+func (r *Report) getAccountsInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: local accounts info has been requested")
+	}
+
+	accounts := Accounts{
+		Users:          []LocalUser{},
+		Groups:         []LocalGroup{},
+		RootAccounts:   []string{},
+		Administrators: []string{},
+		SudoRules:      []SudoRule{},
+	}
+
+	users, err := readPasswd()
+	if err != nil {
+		return err
+	}
+
+	groups, err := readGroups()
+	if err != nil {
+		return err
+	}
+	accounts.Groups = groups
+
+	// The shadow file can only be read by root, the users are reported anyway
+	shadow, err := readShadow()
+	if err != nil {
+		log.Printf("[INFO]: could not read %s, password information won't be reported, reason: %v", SHADOW_PATH, err)
+	}
+
+	accounts.SudoRules = readSudoers(SUDOERS_PATH, 0)
+	sudoUsers, sudoGroups := sudoersPrincipals(accounts.SudoRules)
+
+	lastLogins := readLastLogins(users)
+
+	uidMin := readUIDMin()
+	for i := range users {
+		u := &users[i]
+		u.System = (u.UID < uidMin || u.UID == NOBODY_UID) && u.UID != 0
+
+		// Primary group and supplementary groups
+		for _, g := range groups {
+			if g.GID == u.GID || slices.Contains(g.Members, u.Name) {
+				u.Groups = append(u.Groups, g.Name)
+			}
+		}
+
+		if s, ok := shadow[u.Name]; ok {
+			applyShadow(u, s)
+		}
+
+		if u.UID == 0 {
+			accounts.RootAccounts = append(accounts.RootAccounts, u.Name)
+		}
+
+		u.Administrator = u.UID == 0 || slices.Contains(sudoUsers, u.Name) || slices.Contains(sudoUsers, "ALL")
+		for _, g := range u.Groups {
+			if slices.Contains(adminGroups, g) || slices.Contains(sudoGroups, g) {
+				u.Administrator = true
+			}
+		}
+		if u.Administrator {
+			accounts.Administrators = append(accounts.Administrators, u.Name)
+		}
+
+		if l, ok := lastLogins[u.Name]; ok {
+			u.LastLogin = &l.Time
+			u.LastLoginFrom = l.Host
+		}
+	}
+	accounts.Users = users
+
+	r.Accounts = &accounts
+	log.Printf("[INFO]: local accounts information has been retrieved")
+	return nil
+}
+
+// readPasswd parses name:password:uid:gid:comment:home:shell
+func readPasswd() ([]LocalUser, error) {
+	users := []LocalUser{}
+
+	err := readColonFile(PASSWD_PATH, func(fields []string) {
+		if len(fields) < 7 {
+			return
+		}
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return
+		}
+		gid, _ := strconv.Atoi(fields[3])
+
+		shell := fields[6]
+		users = append(users, LocalUser{
+			Name:     fields[0],
+			UID:      uid,
+			GID:      gid,
+			Comment:  strings.TrimRight(fields[4], ","),
+			Home:     fields[5],
+			Shell:    shell,
+			CanLogin: !strings.HasSuffix(shell, "/nologin") && !strings.HasSuffix(shell, "/false") && shell != "",
+			Password: PASSWORD_UNKNOWN,
+			Groups:   []string{},
+		})
+	})
+
+	return users, err
+}
+
+// readGroups parses name:password:gid:member1,member2
+func readGroups() ([]LocalGroup, error) {
+	groups := []LocalGroup{}
+
+	err := readColonFile(GROUP_PATH, func(fields []string) {
+		if len(fields) < 4 {
+			return
+		}
+		gid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return
+		}
+		g := LocalGroup{Name: fields[0], GID: gid, Members: []string{}}
+		for m := range strings.SplitSeq(fields[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				g.Members = append(g.Members, m)
+			}
+		}
+		groups = append(groups, g)
+	})
+
+	return groups, err
+}
+
+// readShadow keeps the fields of name:password:lastchg:min:max:warn:inactive:expire, the password
+// field is replaced by its status so the hash never leaves this function
+func readShadow() (map[string][]string, error) {
+	shadow := map[string][]string{}
+
+	err := readColonFile(SHADOW_PATH, func(fields []string) {
+		if len(fields) < 8 {
+			return
+		}
+		fields[1] = passwordStatus(fields[1])
+		shadow[fields[0]] = fields
+	})
+
+	return shadow, err
+}
+
+func passwordStatus(hash string) string {
+	switch {
+	case hash == "":
+		return PASSWORD_EMPTY
+	case hash == "*" || hash == "!*" || hash == "!!" || hash == "!":
+		return PASSWORD_NONE
+	case strings.HasPrefix(hash, "!") || strings.HasPrefix(hash, "*"):
+		return PASSWORD_LOCKED
+	default:
+		return PASSWORD_SET
+	}
+}
+
+// applyShadow converts the days since the epoch to dates
+func applyShadow(u *LocalUser, fields []string) {
+	u.Password = fields[1]
+
+	day := func(value string) *time.Time {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			return nil
+		}
+		t := time.Unix(0, 0).UTC().AddDate(0, 0, days)
+		return &t
+	}
+
+	u.PasswordLastChanged = day(fields[2])
+	if maxDays, err := strconv.Atoi(fields[4]); err == nil && maxDays > 0 && maxDays < 99999 {
+		u.PasswordMaxDays = maxDays
+		if u.PasswordLastChanged != nil {
+			expires := u.PasswordLastChanged.AddDate(0, 0, maxDays)
+			u.PasswordExpires = &expires
+		}
+	}
+	u.AccountExpires = day(fields[7])
+}
+
+// readSudoers returns the rules of the file and the files it includes, defaults and
+// aliases other than user aliases are skipped as they don't grant anything by themselves
+func readSudoers(path string, depth int) []SudoRule {
+	rules := []SudoRule{}
+
+	// sudo itself stops at 128 levels, we don't need that many
+	if depth > 8 {
+		return rules
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if depth == 0 {
+			log.Printf("[INFO]: could not read %s, reason: %v", path, err)
+		}
+		return rules
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := ""
+	for scanner.Scan() {
+		// Lines ending with a backslash continue in the next line
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(text, "\\") {
+			line += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+		line += text
+		current := line
+		line = ""
+
+		fields := strings.Fields(current)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "#include", "@include":
+			if len(fields) > 1 {
+				rules = append(rules, readSudoers(sudoersIncludePath(path, fields[1]), depth+1)...)
+			}
+			continue
+		case "#includedir", "@includedir":
+			if len(fields) > 1 {
+				rules = append(rules, readSudoersDir(sudoersIncludePath(path, fields[1]), depth+1)...)
+			}
+			continue
+		}
+
+		// User aliases are kept as rules can be granted to them
+		if strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "Defaults") ||
+			(strings.HasSuffix(fields[0], "_Alias") && fields[0] != "User_Alias") {
+			continue
+		}
+
+		rules = append(rules, SudoRule{File: path, Rule: current})
+	}
+
+	return rules
+}
+
+// readSudoersDir follows sudo rules, files ending in ~ or containing a dot are ignored
+func readSudoersDir(dir string, depth int) []SudoRule {
+	rules := []SudoRule{}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return rules
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), "~") || strings.Contains(e.Name(), ".") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rules = append(rules, readSudoers(filepath.Join(dir, name), depth+1)...)
+	}
+	return rules
+}
+
+// sudoersRuleUsers returns the user list of a rule, it's everything before the host
+// e.g alice, bob ALL=(ALL) ALL, fields can be separated by tabs or spaces around the equal sign
+func sudoersRuleUsers(rule string) []string {
+	fields := strings.Fields(rule)
+
+	host := slices.IndexFunc(fields, func(f string) bool { return strings.Contains(f, "=") })
+	if host < 0 {
+		return nil
+	}
+	// e.g root ALL = (ALL) ALL, the host is the field before the equal sign
+	if strings.HasPrefix(fields[host], "=") {
+		host--
+	}
+	if host < 1 {
+		return nil
+	}
+
+	users := []string{}
+	for u := range strings.SplitSeq(strings.Join(fields[:host], ","), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+func sudoersIncludePath(from, path string) string {
+	path = strings.Trim(path, `"`)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
+
+// sudoersPrincipals returns the users and groups (%group) that rules are granted to,
+// user aliases are expanded
+func sudoersPrincipals(rules []SudoRule) ([]string, []string) {
+	users, groups := []string{}, []string{}
+
+	// User_Alias ADMINS = alice, bob : OPERATORS = %ops
+	aliases := map[string][]string{}
+	for _, r := range rules {
+		definitions, found := strings.CutPrefix(r.Rule, "User_Alias")
+		if !found {
+			continue
+		}
+		for d := range strings.SplitSeq(definitions, ":") {
+			name, members, found := strings.Cut(d, "=")
+			if !found {
+				continue
+			}
+			for m := range strings.SplitSeq(members, ",") {
+				aliases[strings.TrimSpace(name)] = append(aliases[strings.TrimSpace(name)], strings.TrimSpace(m))
+			}
+		}
+	}
+
+	var add func(p string, depth int)
+	add = func(p string, depth int) {
+		switch {
+		case p == "" || strings.HasPrefix(p, "!") || strings.HasPrefix(p, "%:"):
+			// Negations and non-Unix groups
+		case strings.HasPrefix(p, "%"):
+			groups = append(groups, strings.TrimPrefix(p, "%"))
+		case aliases[p] != nil && depth < 8:
+			for _, m := range aliases[p] {
+				add(m, depth+1)
+			}
+		default:
+			users = append(users, p)
+		}
+	}
+
+	for _, r := range rules {
+		if strings.HasPrefix(r.Rule, "User_Alias") {
+			continue
+		}
+		for _, p := range sudoersRuleUsers(r.Rule) {
+			add(p, 0)
+		}
+	}
+
+	return users, groups
+}
+
+func readUIDMin() int {
+	uidMin := DEFAULT_UIDMIN
+
+	_ = readSpaceFile(LOGIN_DEFS, func(fields []string) {
+		if len(fields) >= 2 && fields[0] == "UID_MIN" {
+			if v, err := strconv.Atoi(fields[1]); err == nil {
+				uidMin = v
+			}
+		}
+	})
+
+	return uidMin
+}
+
+type lastLogin struct {
+	Time time.Time
+	Host string
+}
+
+// readLastLogins uses lastlog, indexed by UID, and wtmp for systems without lastlog
+// (lastlog has been replaced by lastlog2 in recent distros)
+func readLastLogins(users []LocalUser) map[string]lastLogin {
+	logins := map[string]lastLogin{}
+
+	if f, err := os.Open(LASTLOG_PATH); err == nil {
+		buf := make([]byte, LASTLOG_RECORD_SIZE)
+		for _, u := range users {
+			if _, err := f.ReadAt(buf, int64(u.UID)*LASTLOG_RECORD_SIZE); err != nil {
+				continue
+			}
+			sec := int64(int32(binary.LittleEndian.Uint32(buf[0:4])))
+			if sec == 0 {
+				continue
+			}
+			logins[u.Name] = lastLogin{Time: time.Unix(sec, 0), Host: cString(buf[36:292])}
+		}
+		f.Close()
+	}
+
+	records, _, err := readUtmpFile(WTMP_PATH, 0)
+	if err != nil {
+		return logins
+	}
+	for _, r := range records {
+		if r.Type != UTMP_USER_PROCESS || r.User == "" {
+			continue
+		}
+		if l, ok := logins[r.User]; !ok || r.Time.After(l.Time) {
+			logins[r.User] = lastLogin{Time: r.Time, Host: r.Host}
+		}
+	}
+
+	return logins
+}
+
+func readColonFile(path string, parse func(fields []string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parse(strings.Split(line, ":"))
+	}
+	return scanner.Err()
+}
+
+func readSpaceFile(path string, parse func(fields []string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parse(strings.Fields(line))
+	}
+	return scanner.Err()
+}
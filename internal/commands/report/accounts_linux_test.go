@@ -0,0 +1,46 @@
+//go:build linux
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSudoersPrincipals(t *testing.T) {
+	sudoers := "# User privilege specification\n" +
+		"Defaults\tenv_reset\n" +
+		"Cmnd_Alias\tSHUTDOWN = /sbin/shutdown\n" +
+		"User_Alias\tADMINS = carol, %wheel\n" +
+		"root\tALL=(ALL:ALL) ALL\n" +
+		"%sudo\tALL=(ALL:ALL) ALL\n" +
+		"alice, bob\tALL=(ALL) NOPASSWD: ALL\n" +
+		"dave,erin ALL = (root) SHUTDOWN\n" +
+		"ADMINS\tALL=(ALL) ALL\n" +
+		"frank\tALL=(ALL) \\\n\tNOPASSWD: ALL\n"
+
+	path := filepath.Join(t.TempDir(), "sudoers")
+	if err := os.WriteFile(path, []byte(sudoers), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := readSudoers(path, 0)
+	if len(rules) != 7 {
+		t.Fatalf("expected 7 rules, got %d: %v", len(rules), rules)
+	}
+
+	users, groups := sudoersPrincipals(rules)
+	slices.Sort(users)
+	slices.Sort(groups)
+
+	expectedUsers := []string{"alice", "bob", "carol", "dave", "erin", "frank", "root"}
+	if !slices.Equal(users, expectedUsers) {
+		t.Errorf("expected users %v, got %v", expectedUsers, users)
+	}
+	expectedGroups := []string{"sudo", "wheel"}
+	if !slices.Equal(groups, expectedGroups) {
+		t.Errorf("expected groups %v, got %v", expectedGroups, groups)
+	}
+}
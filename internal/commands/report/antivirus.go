@@ -2,11 +2,29 @@ package report
 
 import (
 	"fmt"
+	"time"
 )
 
+// SecurityProduct is an antivirus or endpoint protection product found in the endpoint,
+// the Antivirus field in the report keeps the main one for older consoles
+type SecurityProduct struct {
+	Name            string    `json:"name"`
+	Version         string    `json:"version,omitempty"`
+	IsActive        bool      `json:"is_active"`
+	IsUpdated       bool      `json:"is_updated"`
+	DefinitionsDate time.Time `json:"definitions_date,omitempty"`
+}
+
 func (r *Report) logAntivirus() {
 	fmt.Printf("\n** 🛡️ Antivirus *****************************************************************************************************\n")
 	fmt.Printf("%-40s |  %v \n", "Antivirus installed", r.Antivirus.Name)
 	fmt.Printf("%-40s |  %v \n", "Antivirus is active", r.Antivirus.IsActive)
 	fmt.Printf("%-40s |  %t \n", "Antivirus database is updated", r.Antivirus.IsUpdated)
+
+	if len(r.SecurityProducts) > 1 {
+		fmt.Printf("---------------------------------------------------------------------------------------------------------------------\n")
+		for _, p := range r.SecurityProducts {
+			fmt.Printf("%-40s |  %s (active: %t, updated: %t) \n", "Security product", p.Name, p.IsActive, p.IsUpdated)
+		}
+	}
 }
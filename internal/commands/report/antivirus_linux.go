@@ -2,9 +2,238 @@
 
 package report
 
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Definitions older than this are considered outdated
+const AV_DEFINITIONS_MAX_AGE = 72 * time.Hour
+
+type linuxSecurityProduct struct {
+	name        string
+	binaries    []string
+	services    []string
+	definitions []string
+	detect      func(p *SecurityProduct)
+}
+
+var linuxSecurityProducts = []linuxSecurityProduct{
+	{
+		name:        "ClamAV",
+		binaries:    []string{"/usr/sbin/clamd", "/usr/bin/clamscan", "/usr/bin/freshclam"},
+		services:    []string{"clamav-daemon", "clamd@scan", "clamd"},
+		definitions: []string{"/var/lib/clamav"},
+		detect:      detectClamAV,
+	},
+	{
+		name:        "Sophos Protection for Linux",
+		binaries:    []string{"/opt/sophos-spl/bin/wdctl"},
+		services:    []string{"sophos-spl"},
+		definitions: []string{"/opt/sophos-spl/plugins/av/chroot/susi/update_source"},
+	},
+	{
+		name:        "Sophos Anti-Virus",
+		binaries:    []string{"/opt/sophos-av/bin/savdstatus"},
+		services:    []string{"sav-protect"},
+		definitions: []string{"/opt/sophos-av/lib/sav"},
+	},
+	{
+		name:        "ESET Server Security",
+		binaries:    []string{"/opt/eset/efs/sbin/startd"},
+		services:    []string{"efs"},
+		definitions: []string{"/var/opt/eset/efs/lib"},
+	},
+	{
+		name:        "ESET Endpoint Antivirus",
+		binaries:    []string{"/opt/eset/eea/sbin/startd"},
+		services:    []string{"eea"},
+		definitions: []string{"/var/opt/eset/eea/lib"},
+	},
+	{
+		name:     "CrowdStrike Falcon",
+		binaries: []string{"/opt/CrowdStrike/falconctl"},
+		services: []string{"falcon-sensor"},
+		detect:   detectCrowdStrike,
+	},
+	{
+		name:     "Microsoft Defender for Endpoint",
+		binaries: []string{"/opt/microsoft/mdatp/sbin/wdavdaemon"},
+		services: []string{"mdatp"},
+		detect:   detectDefender,
+	},
+	{
+		name:     "SentinelOne",
+		binaries: []string{"/opt/sentinelone/bin/sentinelctl"},
+		services: []string{"sentinelone"},
+		detect:   detectSentinelOne,
+	},
+}
+
 func (r *Report) getAntivirusInfo() error {
 	r.Antivirus.Name = ""
 	r.Antivirus.IsActive = false
 	r.Antivirus.IsUpdated = false
+	r.SecurityProducts = []SecurityProduct{}
+
+	for _, lp := range linuxSecurityProducts {
+		if !anyFileExists(lp.binaries) {
+			continue
+		}
+
+		p := SecurityProduct{Name: lp.name}
+		p.IsActive = isAnyServiceActive(lp.services)
+
+		if len(lp.definitions) > 0 {
+			p.DefinitionsDate = newestModTime(lp.definitions)
+			p.IsUpdated = !p.DefinitionsDate.IsZero() && time.Since(p.DefinitionsDate) < AV_DEFINITIONS_MAX_AGE
+		}
+
+		if lp.detect != nil {
+			lp.detect(&p)
+		}
+
+		r.SecurityProducts = append(r.SecurityProducts, p)
+	}
+
+	// The console shows one antivirus so we choose the first active one
+	for _, p := range r.SecurityProducts {
+		if p.IsActive || r.Antivirus.Name == "" {
+			r.Antivirus.Name = p.Name
+			r.Antivirus.IsActive = p.IsActive
+			r.Antivirus.IsUpdated = p.IsUpdated
+		}
+		if p.IsActive {
+			break
+		}
+	}
+
+	log.Printf("[INFO]: %d security products have been found", len(r.SecurityProducts))
 	return nil
 }
+
+func detectClamAV(p *SecurityProduct) {
+	// The build time stored in the database header is more reliable than the file modification time
+	for _, db := range []string{"daily.cld", "daily.cvd"} {
+		t, version, err := readClamAVHeader(filepath.Join("/var/lib/clamav", db))
+		if err == nil {
+			p.DefinitionsDate = t
+			p.Version = version
+			p.IsUpdated = time.Since(t) < AV_DEFINITIONS_MAX_AGE
+			break
+		}
+	}
+}
+
+// readClamAVHeader parses the 512 bytes header of a ClamAV database, the time has no colon
+// e.g ClamAV-VDB:14 Oct 2025 07-18 -0400:27792:2075395:90:...
+func readClamAVHeader(path string) (time.Time, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	if _, err := f.Read(header); err != nil {
+		return time.Time{}, "", err
+	}
+
+	fields := strings.Split(string(bytes.TrimRight(header, "\x00 ")), ":")
+	if len(fields) < 3 || fields[0] != "ClamAV-VDB" {
+		return time.Time{}, "", fs.ErrInvalid
+	}
+
+	t, err := time.Parse("02 Jan 2006 15-04 -0700", fields[1])
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	return t, fields[2], nil
+}
+
+func detectCrowdStrike(p *SecurityProduct) {
+	// Falcon has no local definitions, it's considered outdated when it runs in reduced functionality mode
+	out, err := exec.Command("/opt/CrowdStrike/falconctl", "-g", "--rfm-state", "--version").Output()
+	if err != nil {
+		p.IsUpdated = p.IsActive
+		return
+	}
+
+	output := string(out)
+	p.IsUpdated = p.IsActive && strings.Contains(output, "rfm-state=false")
+	if _, version, found := strings.Cut(output, "version = "); found {
+		p.Version = strings.TrimSpace(strings.Split(version, "\n")[0])
+	}
+}
+
+func detectDefender(p *SecurityProduct) {
+	if out, err := exec.Command("mdatp", "health", "--field", "real_time_protection_enabled").Output(); err == nil {
+		p.IsActive = p.IsActive && strings.TrimSpace(string(out)) == "true"
+	}
+
+	if out, err := exec.Command("mdatp", "health", "--field", "definitions_status").Output(); err == nil {
+		p.IsUpdated = strings.Contains(string(out), "up_to_date")
+	}
+
+	if out, err := exec.Command("mdatp", "health", "--field", "app_version").Output(); err == nil {
+		p.Version = strings.Trim(strings.TrimSpace(string(out)), `"`)
+	}
+}
+
+func detectSentinelOne(p *SecurityProduct) {
+	// SentinelOne is cloud driven, the agent reports if it's connected to its management
+	out, err := exec.Command("/opt/sentinelone/bin/sentinelctl", "management", "status").Output()
+	if err != nil {
+		p.IsUpdated = p.IsActive
+		return
+	}
+	status := strings.ToLower(string(out))
+	p.IsUpdated = p.IsActive && strings.Contains(status, "connected") && !strings.Contains(status, "disconnected")
+
+	if out, err := exec.Command("/opt/sentinelone/bin/sentinelctl", "version").Output(); err == nil {
+		p.Version = strings.TrimSpace(string(out))
+	}
+}
+
+func isAnyServiceActive(services []string) bool {
+	for _, s := range services {
+		if err := exec.Command("systemctl", "is-active", "--quiet", s).Run(); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func anyFileExists(paths []string) bool {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newestModTime returns the most recent modification time of the files in the folders
+func newestModTime(folders []string) time.Time {
+	newest := time.Time{}
+	for _, folder := range folders {
+		_ = filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err == nil && info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return newest
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadClamAVHeader(t *testing.T) {
+	header := "ClamAV-VDB:14 Oct 2025 07-18 -0400:27792:2075395:90:5f1bd9b2a8e2d2c1a6dc1eb70cf36a3c:X:raynman:1760440726"
+	data := make([]byte, 512)
+	copy(data, header)
+
+	path := filepath.Join(t.TempDir(), "daily.cvd")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, version, err := readClamAVHeader(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := time.Date(2025, time.October, 14, 11, 18, 0, 0, time.UTC)
+	if !updated.Equal(expected) {
+		t.Errorf("expected time %v, got %v", expected, updated.UTC())
+	}
+	if version != "27792" {
+		t.Errorf("expected version 27792, got %s", version)
+	}
+}
+
+func TestReadClamAVHeaderInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.cld")
+	if err := os.WriteFile(path, []byte("not a database"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := readClamAVHeader(path); err == nil {
+		t.Error("expected an error for a file without ClamAV header")
+	}
+}
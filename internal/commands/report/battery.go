@@ -0,0 +1,56 @@
+package report
+
+import (
+	"fmt"
+)
+
+const (
+	BATTERY_CAPACITY_MWH = "mWh"
+	BATTERY_CAPACITY_MAH = "mAh"
+)
+
+type Battery struct {
+	Name               string `json:"name"`
+	Manufacturer       string `json:"manufacturer,omitempty"`
+	Model              string `json:"model,omitempty"`
+	Serial             string `json:"serial,omitempty"`
+	Chemistry          string `json:"chemistry,omitempty"`
+	DesignCapacity     int    `json:"design_capacity,omitempty"`
+	FullChargeCapacity int    `json:"full_charge_capacity,omitempty"`
+	CapacityUnit       string `json:"capacity_unit,omitempty"`
+	CycleCount         int    `json:"cycle_count,omitempty"`
+	// Health is the full charge capacity as a percentage of the design capacity
+	Health int    `json:"health"`
+	Status string `json:"status,omitempty"`
+}
+
+// batteryHealth is capped at 100 as new batteries can hold a bit more than their design capacity
+func batteryHealth(fullCharge, design int) int {
+	if design <= 0 || fullCharge <= 0 {
+		return 0
+	}
+	return min(fullCharge*100/design, 100)
+}
+
+func (r *Report) logBatteries() {
+	if len(r.Batteries) == 0 {
+		return
+	}
+
+	fmt.Printf("\n** 🔋 Batteries *****************************************************************************************************\n")
+	for i, b := range r.Batteries {
+		fmt.Printf("%-40s |  %s \n", "Battery", b.Name)
+		fmt.Printf("%-40s |  %s \n", "Manufacturer", b.Manufacturer)
+		fmt.Printf("%-40s |  %s \n", "Model", b.Model)
+		fmt.Printf("%-40s |  %s \n", "Serial Number", b.Serial)
+		fmt.Printf("%-40s |  %s \n", "Chemistry", b.Chemistry)
+		fmt.Printf("%-40s |  %d %s \n", "Design Capacity", b.DesignCapacity, b.CapacityUnit)
+		fmt.Printf("%-40s |  %d %s \n", "Full Charge Capacity", b.FullChargeCapacity, b.CapacityUnit)
+		fmt.Printf("%-40s |  %d \n", "Cycle Count", b.CycleCount)
+		fmt.Printf("%-40s |  %d %% \n", "Health", b.Health)
+
+		if len(r.Batteries) > 1 && i+1 != len(r.Batteries) {
+			fmt.Printf("---------------------------------------------------------------------------------------------------------------------\n")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//go:build darwin
+
+package report
+
+import (
+	"encoding/json"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+type SPPowerDataType struct {
+	SPPowerDataType []struct {
+		Name       string `json:"_name"`
+		HealthInfo struct {
+			CycleCount      int    `json:"sppower_battery_cycle_count"`
+			Condition       string `json:"sppower_battery_health"`
+			MaximumCapacity string `json:"sppower_battery_health_maximum_capacity"`
+		} `json:"sppower_battery_health_info"`
+		ModelInfo struct {
+			DeviceName   string `json:"sppower_battery_device_name"`
+			Manufacturer string `json:"sppower_battery_manufacturer"`
+			SerialNumber string `json:"sppower_battery_serial_number"`
+		} `json:"sppower_battery_model_info"`
+	} `json:"SPPowerDataType"`
+}
+
+func (r *Report) getBatteriesInfo(debug bool) error {
+	var data SPPowerDataType
+
+	if debug {
+		log.Println("[DEBUG]: batteries info has been requested")
+	}
+
+	r.Batteries = []Battery{}
+
+	out, err := exec.Command("system_profiler", "-json", "SPPowerDataType").Output()
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(out, &data); err != nil {
+		return err
+	}
+
+	// System Profiler doesn't give the capacities, only the maximum capacity as a percentage
+	for _, item := range data.SPPowerDataType {
+		if item.Name != "spbattery_information" {
+			continue
+		}
+
+		b := Battery{
+			Name:         "Battery",
+			Manufacturer: item.ModelInfo.Manufacturer,
+			Model:        item.ModelInfo.DeviceName,
+			Serial:       item.ModelInfo.SerialNumber,
+			CycleCount:   item.HealthInfo.CycleCount,
+			Status:       item.HealthInfo.Condition,
+		}
+		if health, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(item.HealthInfo.MaximumCapacity), "%")); err == nil {
+			b.Health = health
+		}
+
+		r.Batteries = append(r.Batteries, b)
+	}
+
+	log.Printf("[INFO]: batteries information has been retrieved from System Profiler")
+	return nil
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package report
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const POWER_SUPPLY_DIR = "/sys/class/power_supply"
+
+func (r *Report) getBatteriesInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: batteries info has been requested")
+	}
+
+	r.Batteries = []Battery{}
+
+	paths, err := filepath.Glob(filepath.Join(POWER_SUPPLY_DIR, "BAT*"))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		if readSysfsValue(p, "type") != "Battery" || readSysfsValue(p, "present") == "0" {
+			continue
+		}
+
+		b := Battery{
+			Name:         filepath.Base(p),
+			Manufacturer: readSysfsValue(p, "manufacturer"),
+			Model:        readSysfsValue(p, "model_name"),
+			Serial:       readSysfsValue(p, "serial_number"),
+			Chemistry:    readSysfsValue(p, "technology"),
+			CycleCount:   readSysfsInt(p, "cycle_count"),
+			Status:       readSysfsValue(p, "status"),
+		}
+
+		// Drivers report energy (µWh) or charge (µAh) depending on the firmware
+		if design := readSysfsInt(p, "energy_full_design"); design > 0 {
+			b.DesignCapacity = design / 1000
+			b.FullChargeCapacity = readSysfsInt(p, "energy_full") / 1000
+			b.CapacityUnit = BATTERY_CAPACITY_MWH
+		} else if design := readSysfsInt(p, "charge_full_design"); design > 0 {
+			b.DesignCapacity = design / 1000
+			b.FullChargeCapacity = readSysfsInt(p, "charge_full") / 1000
+			b.CapacityUnit = BATTERY_CAPACITY_MAH
+		}
+		b.Health = batteryHealth(b.FullChargeCapacity, b.DesignCapacity)
+
+		r.Batteries = append(r.Batteries, b)
+	}
+
+	log.Printf("[INFO]: batteries information has been retrieved from %s", POWER_SUPPLY_DIR)
+	return nil
+}
+
+func readSysfsValue(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readSysfsInt(dir, name string) int {
+	value, err := strconv.Atoi(readSysfsValue(dir, name))
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+//go:build windows
+
+package report
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+func (r *Report) getBatteriesInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: batteries info has been requested")
+	}
+
+	// Battery classes are provided by the ACPI battery driver in the WMI namespace,
+	// every class has one instance per battery identified by InstanceName
+	var staticDst []struct {
+		InstanceName     string
+		DeviceName       string
+		ManufactureName  string
+		SerialNumber     string
+		Chemistry        uint32
+		DesignedCapacity uint32
+	}
+	var fullChargeDst []struct {
+		InstanceName        string
+		FullChargedCapacity uint32
+	}
+	var cycleCountDst []struct {
+		InstanceName string
+		CycleCount   uint32
+	}
+
+	r.Batteries = []Battery{}
+
+	namespace := `root\WMI`
+	ctx := context.Background()
+
+	if err := WMIQueryWithContext(ctx, "SELECT InstanceName, DeviceName, ManufactureName, SerialNumber, Chemistry, DesignedCapacity FROM BatteryStaticData", &staticDst, namespace); err != nil {
+		// Desktops have no battery and the class has no instances
+		log.Printf("[INFO]: no battery information found in WMI BatteryStaticData: %v", err)
+		return nil
+	}
+
+	fullCharge := map[string]int{}
+	if err := WMIQueryWithContext(ctx, "SELECT InstanceName, FullChargedCapacity FROM BatteryFullChargedCapacity", &fullChargeDst, namespace); err == nil {
+		for _, v := range fullChargeDst {
+			fullCharge[v.InstanceName] = int(v.FullChargedCapacity)
+		}
+	}
+
+	cycleCount := map[string]int{}
+	if err := WMIQueryWithContext(ctx, "SELECT InstanceName, CycleCount FROM BatteryCycleCount", &cycleCountDst, namespace); err == nil {
+		for _, v := range cycleCountDst {
+			cycleCount[v.InstanceName] = int(v.CycleCount)
+		}
+	}
+
+	for _, v := range staticDst {
+		b := Battery{
+			Name:               v.InstanceName,
+			Manufacturer:       strings.TrimSpace(v.ManufactureName),
+			Model:              strings.TrimSpace(v.DeviceName),
+			Serial:             strings.TrimSpace(v.SerialNumber),
+			Chemistry:          batteryChemistry(v.Chemistry),
+			DesignCapacity:     int(v.DesignedCapacity),
+			FullChargeCapacity: fullCharge[v.InstanceName],
+			CapacityUnit:       BATTERY_CAPACITY_MWH,
+			CycleCount:         cycleCount[v.InstanceName],
+		}
+		b.Health = batteryHealth(b.FullChargeCapacity, b.DesignCapacity)
+		r.Batteries = append(r.Batteries, b)
+	}
+
+	log.Printf("[INFO]: batteries information has been retrieved from WMI")
+	return nil
+}
+
+// batteryChemistry decodes the four ASCII characters packed in the Chemistry field e.g LION
+func batteryChemistry(chemistry uint32) string {
+	b := []byte{byte(chemistry), byte(chemistry >> 8), byte(chemistry >> 16), byte(chemistry >> 24)}
+	return strings.TrimSpace(strings.Trim(string(b), "\x00"))
+}
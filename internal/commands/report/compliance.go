@@ -0,0 +1,23 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/scncore/scnorion-agent/internal/commands/compliance"
+)
+
+func (r *Report) logCompliance() {
+	if r.Compliance == nil {
+		return
+	}
+
+	fmt.Printf("\n** ✅ Compliance ****************************************************************************************************\n")
+	fmt.Printf("%-40s |  %s \n", "Policy", r.Compliance.PolicyName)
+	fmt.Printf("%-40s |  %.2f%% \n", "Score", r.Compliance.Score)
+	fmt.Printf("%-40s |  %d passed, %d failed, %d not applicable, %d errors \n", "Rules", r.Compliance.Passed, r.Compliance.Failed, r.Compliance.NotApplicable, r.Compliance.Errors)
+	for _, result := range r.Compliance.Results {
+		if result.Status == compliance.RESULT_FAIL || result.Status == compliance.RESULT_ERROR {
+			fmt.Printf("%-40s |  %s: %s \n", result.ID, result.Status, result.Evidence)
+		}
+	}
+}
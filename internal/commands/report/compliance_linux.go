@@ -0,0 +1,30 @@
+//go:build linux
+
+package report
+
+import (
+	"log"
+
+	"github.com/scncore/scnorion-agent/internal/commands/compliance"
+)
+
+// getComplianceInfo evaluates the last policy received from the console, if any
+func (r *Report) getComplianceInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: compliance info has been requested")
+	}
+
+	p, err := compliance.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	r.Compliance = compliance.Evaluate(p)
+	r.Compliance.AgentID = r.AgentID
+
+	log.Printf("[INFO]: compliance policy %s has been evaluated, score: %.2f", p.Name, r.Compliance.Score)
+	return nil
+}
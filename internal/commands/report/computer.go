@@ -13,4 +13,10 @@ func (r *Report) logComputer() {
 	fmt.Printf("%-40s |  %s \n", "Processor Architecture", r.Computer.ProcessorArch)
 	fmt.Printf("%-40s |  %d \n", "Number of Cores", r.Computer.ProcessorCores)
 	fmt.Printf("%-40s |  %d MB \n", "RAM Memory", r.Computer.Memory)
+	if r.ComputerDetails != nil {
+		fmt.Printf("%-40s |  %s \n", "SKU", r.ComputerDetails.SKU)
+		fmt.Printf("%-40s |  %s \n", "Chassis Type", r.ComputerDetails.ChassisType)
+		fmt.Printf("%-40s |  %s \n", "Asset Tag", r.ComputerDetails.AssetTag)
+		fmt.Printf("%-40s |  %s %s (%s) \n", "BIOS", r.ComputerDetails.BIOSVendor, r.ComputerDetails.BIOSVersion, r.ComputerDetails.BIOSDate)
+	}
 }
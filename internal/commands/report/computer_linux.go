@@ -46,14 +46,26 @@ func (r *Report) getComputerInfo(debug bool) error {
 func (r *Report) getComputerSystemInfo() error {
 	var si sysinfo.SysInfo
 
-	si.GetSysInfo()
+	// SMBIOS is read first, SysInfo uses the DMI sysfs entries that have less information
+	if err := r.getSMBIOSComputerInfo(); err != nil {
+		log.Printf("[INFO]: could not read SMBIOS table, reason: %v", err)
+	}
+
+	if r.Computer.Manufacturer == "" || r.Computer.Model == "" {
+		si.GetSysInfo()
+
+		if r.Computer.Manufacturer == "" {
+			r.Computer.Manufacturer = strings.TrimSpace(si.Product.Vendor)
+		}
+		if r.Computer.Model == "" {
+			r.Computer.Model = strings.TrimSpace(si.Product.Name)
+		}
+	}
 
-	r.Computer.Manufacturer = strings.TrimSpace(si.Product.Vendor)
 	if r.Computer.Manufacturer == "" {
 		r.Computer.Manufacturer = "Unknown"
 	}
-	r.Computer.Model = strings.TrimSpace(si.Product.Name)
-	if r.Computer.Model == "System Product Name" {
+	if r.Computer.Model == "" || r.Computer.Model == "System Product Name" {
 		r.Computer.Model = "Unknown"
 	}
 	r.Computer.Memory = sysTotalMemory()
@@ -63,10 +75,12 @@ func (r *Report) getComputerSystemInfo() error {
 func (r *Report) getSerialNumber() error {
 	var si sysinfo.SysInfo
 
-	si.GetSysInfo()
+	if r.Computer.Serial == "" {
+		si.GetSysInfo()
+		r.Computer.Serial = si.Product.Serial
+	}
 
-	r.Computer.Serial = si.Product.Serial
-	if r.Computer.Serial == "System Serial Number" {
+	if r.Computer.Serial == "" || r.Computer.Serial == "System Serial Number" {
 		r.Computer.Serial = "Unknown"
 	}
 	return nil
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"fmt"
+)
+
+const (
+	DEVICE_BUS_PCI = "pci"
+	DEVICE_BUS_USB = "usb"
+)
+
+// Device is a PCI or USB device, names are resolved using the pci.ids and usb.ids databases
+type Device struct {
+	Bus       string `json:"bus"`
+	Address   string `json:"address"`
+	VendorID  string `json:"vendor_id"`
+	ProductID string `json:"product_id"`
+	Vendor    string `json:"vendor,omitempty"`
+	Product   string `json:"product,omitempty"`
+	Class     string `json:"class"`
+	ClassName string `json:"class_name,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Serial    string `json:"serial,omitempty"`
+	// Driver is empty if no kernel driver is bound to the device
+	Driver string `json:"driver,omitempty"`
+}
+
+type GPU struct {
+	Address string `json:"address"`
+	Vendor  string `json:"vendor"`
+	Model   string `json:"model"`
+	Driver  string `json:"driver,omitempty"`
+	// VRAM is in MB, integrated graphics share the system memory so it's 0
+	VRAM int `json:"vram,omitempty"`
+}
+
+func (r *Report) logDevices() {
+	fmt.Printf("\n** 🧩 Devices *******************************************************************************************************\n")
+	if len(r.GPUs) == 0 && len(r.Devices) == 0 {
+		fmt.Printf("%-40s\n", "No devices found")
+		return
+	}
+
+	for _, g := range r.GPUs {
+		fmt.Printf("%-40s |  %s %s (%d MB, driver: %s) \n", "GPU "+g.Address, g.Vendor, g.Model, g.VRAM, g.Driver)
+	}
+
+	for _, d := range r.Devices {
+		name := d.Product
+		if name == "" {
+			name = d.VendorID + ":" + d.ProductID
+		}
+		fmt.Printf("%-40s |  %s %s (driver: %s) \n", d.Bus+" "+d.Address, d.Vendor, name, d.Driver)
+	}
+}
@@ -0,0 +1,329 @@
+//go:build linux
+
+package report
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	PCI_DEVICES_DIR = "/sys/bus/pci/devices"
+	USB_DEVICES_DIR = "/sys/bus/usb/devices"
+)
+
+// Locations used by hwdata, pciutils and usbutils in the different distros
+var pciIDsPaths = []string{"/usr/share/hwdata/pci.ids", "/usr/share/misc/pci.ids", "/usr/share/pci.ids"}
+var usbIDsPaths = []string{"/usr/share/hwdata/usb.ids", "/usr/share/misc/usb.ids", "/usr/share/usb.ids"}
+
+// PCI display controllers class
+const PCI_CLASS_DISPLAY = "03"
+
+type hardwareIDs struct {
+	vendors    map[string]string
+	products   map[string]string
+	subsystems map[string]string
+	classes    map[string]string
+}
+
+func (r *Report) getDevicesInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: devices info has been requested")
+	}
+
+	r.Devices = []Device{}
+	r.GPUs = []GPU{}
+
+	pciIDs := readHardwareIDs(pciIDsPaths)
+	usbIDs := readHardwareIDs(usbIDsPaths)
+
+	if err := r.getPCIDevices(pciIDs); err != nil {
+		log.Printf("[ERROR]: could not read PCI devices, reason: %v", err)
+	}
+
+	if err := r.getUSBDevices(usbIDs); err != nil {
+		log.Printf("[ERROR]: could not read USB devices, reason: %v", err)
+	}
+
+	log.Printf("[INFO]: devices information has been retrieved from sysfs")
+	return nil
+}
+
+func (r *Report) getPCIDevices(ids *hardwareIDs) error {
+	entries, err := os.ReadDir(PCI_DEVICES_DIR)
+	if err != nil {
+		return err
+	}
+
+	nvidiaVRAM := getNvidiaVRAM()
+
+	for _, e := range entries {
+		dir := filepath.Join(PCI_DEVICES_DIR, e.Name())
+
+		// class is 0xCCSSPP, class, subclass and programming interface
+		class := strings.TrimPrefix(readSysfsValue(dir, "class"), "0x")
+		if len(class) < 4 {
+			continue
+		}
+
+		d := Device{
+			Bus:       DEVICE_BUS_PCI,
+			Address:   e.Name(),
+			VendorID:  strings.TrimPrefix(readSysfsValue(dir, "vendor"), "0x"),
+			ProductID: strings.TrimPrefix(readSysfsValue(dir, "device"), "0x"),
+			Class:     class[:4],
+			Driver:    boundDriver(dir),
+		}
+		d.Vendor = ids.vendors[d.VendorID]
+		d.Product = ids.products[d.VendorID+":"+d.ProductID]
+		d.ClassName = ids.className(class[:2], class[2:4])
+
+		subVendor := strings.TrimPrefix(readSysfsValue(dir, "subsystem_vendor"), "0x")
+		subDevice := strings.TrimPrefix(readSysfsValue(dir, "subsystem_device"), "0x")
+		if name, ok := ids.subsystems[d.VendorID+":"+d.ProductID+":"+subVendor+":"+subDevice]; ok {
+			d.Subsystem = name
+		} else if subVendor != "" && subVendor != d.VendorID {
+			d.Subsystem = ids.vendors[subVendor]
+		}
+
+		r.Devices = append(r.Devices, d)
+
+		if class[:2] == PCI_CLASS_DISPLAY {
+			gpu := GPU{
+				Address: d.Address,
+				Vendor:  d.Vendor,
+				Model:   d.Product,
+				Driver:  d.Driver,
+			}
+			if gpu.Model == "" {
+				gpu.Model = d.VendorID + ":" + d.ProductID
+			}
+
+			// amdgpu exports the VRAM size in bytes, for nvidia we ask nvidia-smi
+			if vram := readSysfsInt(dir, "mem_info_vram_total"); vram > 0 {
+				gpu.VRAM = vram / 1024 / 1024
+			} else {
+				gpu.VRAM = nvidiaVRAM[pciBusAddress(d.Address)]
+			}
+
+			r.GPUs = append(r.GPUs, gpu)
+		}
+	}
+
+	return nil
+}
+
+func (r *Report) getUSBDevices(ids *hardwareIDs) error {
+	entries, err := os.ReadDir(USB_DEVICES_DIR)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		// Interfaces contain a colon and root hubs are named usbN
+		if strings.Contains(e.Name(), ":") || strings.HasPrefix(e.Name(), "usb") {
+			continue
+		}
+
+		dir := filepath.Join(USB_DEVICES_DIR, e.Name())
+
+		d := Device{
+			Bus:       DEVICE_BUS_USB,
+			Address:   e.Name(),
+			VendorID:  readSysfsValue(dir, "idVendor"),
+			ProductID: readSysfsValue(dir, "idProduct"),
+			Serial:    readSysfsValue(dir, "serial"),
+			Class:     readSysfsValue(dir, "bDeviceClass"),
+		}
+		if d.VendorID == "" {
+			continue
+		}
+
+		// The names stored in the device are preferred as usb.ids is often outdated
+		d.Vendor = readSysfsValue(dir, "manufacturer")
+		if d.Vendor == "" {
+			d.Vendor = ids.vendors[d.VendorID]
+		}
+		d.Product = readSysfsValue(dir, "product")
+		if d.Product == "" {
+			d.Product = ids.products[d.VendorID+":"+d.ProductID]
+		}
+
+		// Composite devices have class 00 and every interface has its own class and driver
+		drivers := []string{}
+		interfaces, _ := filepath.Glob(filepath.Join(dir, e.Name()+":*"))
+		for _, i := range interfaces {
+			if d.Class == "00" {
+				d.Class = readSysfsValue(i, "bInterfaceClass")
+			}
+			if driver := boundDriver(i); driver != "" && !slices.Contains(drivers, driver) {
+				drivers = append(drivers, driver)
+			}
+		}
+		d.Driver = strings.Join(drivers, ",")
+		d.ClassName = ids.className(d.Class, "")
+
+		r.Devices = append(r.Devices, d)
+	}
+
+	return nil
+}
+
+// boundDriver returns the name of the driver the device is bound to, the driver entry is a symlink
+func boundDriver(dir string) string {
+	link, err := os.Readlink(filepath.Join(dir, "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(link)
+}
+
+// pciBusAddress removes the domain from a PCI address as nvidia-smi uses a longer one
+// e.g 0000:01:00.0 and 00000000:01:00.0 -> 01:00.0
+func pciBusAddress(address string) string {
+	_, busAddress, found := strings.Cut(strings.ToLower(address), ":")
+	if !found {
+		return address
+	}
+	return busAddress
+}
+
+// getNvidiaVRAM returns the memory in MB of every nvidia GPU by PCI address
+func getNvidiaVRAM() map[string]int {
+	vram := map[string]int{}
+
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return vram
+	}
+
+	out, err := exec.Command("nvidia-smi", "--query-gpu=pci.bus_id,memory.total", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		log.Printf("[INFO]: could not get the GPU memory from nvidia-smi, reason: %v", err)
+		return vram
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		busID, memory, found := strings.Cut(line, ",")
+		if !found {
+			continue
+		}
+		if mb, err := strconv.Atoi(strings.TrimSpace(memory)); err == nil {
+			vram[pciBusAddress(strings.TrimSpace(busID))] = mb
+		}
+	}
+
+	return vram
+}
+
+// readHardwareIDs parses the first pci.ids or usb.ids file found. Both use the same format:
+//
+//	vendor  vendor_name
+//		device  device_name
+//			subvendor subdevice  subsystem_name
+//	C class  class_name
+//		subclass  subclass_name
+//
+// other sections of usb.ids (HID, languages...) are ignored
+func readHardwareIDs(paths []string) *hardwareIDs {
+	ids := &hardwareIDs{
+		vendors:    map[string]string{},
+		products:   map[string]string{},
+		subsystems: map[string]string{},
+		classes:    map[string]string{},
+	}
+
+	var f *os.File
+	var err error
+	for _, p := range paths {
+		if f, err = os.Open(p); err == nil {
+			break
+		}
+	}
+	if f == nil {
+		log.Printf("[INFO]: hardware IDs database not found in %s, device names won't be resolved", strings.Join(paths, ", "))
+		return ids
+	}
+	defer f.Close()
+
+	const (
+		sectionNone = iota
+		sectionVendor
+		sectionClass
+	)
+	section := sectionNone
+	vendor, device, class := "", "", ""
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "\t\t"):
+			if section != sectionVendor || device == "" {
+				continue
+			}
+			// subvendor subdevice  subsystem_name
+			fields := strings.Fields(line)
+			if len(fields) >= 3 {
+				ids.subsystems[vendor+":"+device+":"+fields[0]+":"+fields[1]] = strings.Join(fields[2:], " ")
+			}
+		case strings.HasPrefix(line, "\t"):
+			id, name := splitHardwareID(strings.TrimPrefix(line, "\t"))
+			switch section {
+			case sectionVendor:
+				device = id
+				ids.products[vendor+":"+device] = name
+			case sectionClass:
+				ids.classes[class+":"+id] = name
+			}
+		case strings.HasPrefix(line, "C "):
+			section = sectionClass
+			id, name := splitHardwareID(strings.TrimPrefix(line, "C "))
+			class = id
+			ids.classes[class] = name
+		case isHexID(line):
+			section = sectionVendor
+			id, name := splitHardwareID(line)
+			vendor, device = id, ""
+			ids.vendors[vendor] = name
+		default:
+			section = sectionNone
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("[ERROR]: could not read hardware IDs database %s, reason: %v", f.Name(), err)
+	}
+
+	return ids
+}
+
+// className returns the subclass name if it's known or the class name
+func (ids *hardwareIDs) className(class, subclass string) string {
+	if name, ok := ids.classes[class+":"+subclass]; ok && subclass != "" {
+		return name
+	}
+	return ids.classes[class]
+}
+
+func splitHardwareID(line string) (string, string) {
+	id, name, _ := strings.Cut(line, " ")
+	return strings.ToLower(id), strings.TrimSpace(name)
+}
+
+func isHexID(line string) bool {
+	if len(line) < 5 || line[4] != ' ' {
+		return false
+	}
+	_, err := strconv.ParseUint(line[:4], 16, 16)
+	return err == nil
+}
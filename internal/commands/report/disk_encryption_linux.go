@@ -0,0 +1,262 @@
+//go:build linux
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+)
+
+type lsblkNode struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Children []lsblkNode `json:"children"`
+}
+
+type lsblkTree struct {
+	Devices []lsblkNode `json:"blockdevices"`
+}
+
+func getVolumeEncryption(m *mountinfo.Info) (EncryptedVolume, error) {
+	volume := EncryptedVolume{Mountpoint: m.Mountpoint, Device: m.Source}
+
+	if m.FSType == "zfs" {
+		cipher, err := getZFSEncryption(m.Source)
+		if err != nil {
+			return volume, err
+		}
+		if cipher != "" {
+			volume.Encrypted = true
+			volume.Method = "ZFS native encryption"
+			volume.Cipher = cipher
+		}
+		return volume, nil
+	}
+
+	if strings.HasPrefix(m.Source, "/dev/") {
+		method, cipher, err := getBlockDeviceEncryption(m.Source)
+		if err != nil {
+			return volume, err
+		}
+		if method != "" {
+			volume.Encrypted = true
+			volume.Method = method
+			volume.Cipher = cipher
+			return volume, nil
+		}
+	}
+
+	// No dm-crypt in the block device stack, the filesystem may use fscrypt
+	if encrypted, cipher := getFscryptPolicy(m.Mountpoint); encrypted {
+		volume.Encrypted = true
+		volume.Method = "fscrypt"
+		volume.Cipher = cipher
+	}
+
+	return volume, nil
+}
+
+// getBlockDeviceEncryption walks the device mapper stack of the device looking for a dm-crypt target
+func getBlockDeviceEncryption(device string) (string, string, error) {
+	dev, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return "", "", err
+	}
+
+	method, cipher, err := getSysfsBlockEncryption(filepath.Base(dev))
+	if err != nil {
+		// sysfs may not know this device, lsblk is slower but it can tell us
+		return getLsblkEncryption(dev)
+	}
+	return method, cipher, nil
+}
+
+func getSysfsBlockEncryption(name string) (string, string, error) {
+	sysPath := filepath.Join("/sys/class/block", name)
+	if _, err := os.Stat(sysPath); err != nil {
+		return "", "", err
+	}
+
+	// Device mapper uuid is CRYPT-LUKS2-..., CRYPT-LUKS1-..., CRYPT-PLAIN-... for dm-crypt targets
+	uuid, err := os.ReadFile(filepath.Join(sysPath, "dm", "uuid"))
+	if err == nil && strings.HasPrefix(string(uuid), "CRYPT-") {
+		kind := strings.Split(strings.TrimPrefix(strings.TrimSpace(string(uuid)), "CRYPT-"), "-")[0]
+		method := "dm-crypt"
+		if strings.HasPrefix(kind, "LUKS") {
+			method = kind
+		}
+
+		dmName, err := os.ReadFile(filepath.Join(sysPath, "dm", "name"))
+		if err != nil {
+			return method, "", nil
+		}
+		return method, getDMCryptCipher(strings.TrimSpace(string(dmName))), nil
+	}
+
+	// Disks and partitions have no slaves, LVM and RAID devices are encrypted only if all their slaves are
+	slaves, err := os.ReadDir(filepath.Join(sysPath, "slaves"))
+	if err != nil || len(slaves) == 0 {
+		return "", "", nil
+	}
+
+	method, cipher := "", ""
+	for _, s := range slaves {
+		m, c, err := getSysfsBlockEncryption(s.Name())
+		if err != nil || m == "" {
+			return "", "", err
+		}
+		method, cipher = m, c
+	}
+
+	return method, cipher, nil
+}
+
+func getLsblkEncryption(device string) (string, string, error) {
+	var tree lsblkTree
+
+	out, err := exec.Command("lsblk", "--json", "--inverse", "-o", "name,type", device).Output()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := json.Unmarshal(out, &tree); err != nil {
+		return "", "", err
+	}
+
+	var findCrypt func(nodes []lsblkNode) string
+	findCrypt = func(nodes []lsblkNode) string {
+		for _, n := range nodes {
+			if n.Type == "crypt" {
+				return n.Name
+			}
+			if name := findCrypt(n.Children); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+
+	if name := findCrypt(tree.Devices); name != "" {
+		return "dm-crypt", getDMCryptCipher(name), nil
+	}
+	return "", "", nil
+}
+
+// getDMCryptCipher reads the cipher from the crypt target table
+// e.g 0 1998848 crypt aes-xts-plain64 :64:logon:cryptsetup:... 0 259:3 32768
+func getDMCryptCipher(dmName string) string {
+	out, err := exec.Command("dmsetup", "table", dmName).Output()
+	if err == nil {
+		fields := strings.Fields(string(out))
+		if len(fields) > 3 && fields[2] == "crypt" {
+			return fields[3]
+		}
+	}
+
+	out, err = exec.Command("cryptsetup", "status", dmName).Output()
+	if err != nil {
+		return ""
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), ":"); found && key == "cipher" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func getZFSEncryption(dataset string) (string, error) {
+	out, err := exec.Command("zfs", "get", "-H", "-o", "value", "encryption", dataset).Output()
+	if err != nil {
+		return "", err
+	}
+
+	cipher := strings.TrimSpace(string(out))
+	if cipher == "off" || cipher == "-" {
+		return "", nil
+	}
+	return cipher, nil
+}
+
+// getFscryptPolicy checks if the directory has an fscrypt encryption policy
+func getFscryptPolicy(path string) (bool, string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, ""
+	}
+	defer f.Close()
+
+	var policy unix.FscryptPolicyV1
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), unix.FS_IOC_GET_ENCRYPTION_POLICY, uintptr(unsafe.Pointer(&policy)))
+	switch {
+	case errno == 0:
+		return true, fscryptModeName(policy.Contents_encryption_mode)
+	case errno == unix.EINVAL:
+		// The directory is encrypted with a v2 policy that the old ioctl can't return
+		return true, ""
+	default:
+		return false, ""
+	}
+}
+
+func fscryptModeName(mode uint8) string {
+	switch mode {
+	case unix.FSCRYPT_MODE_AES_256_XTS:
+		return "aes-256-xts"
+	case unix.FSCRYPT_MODE_AES_128_CBC:
+		return "aes-128-cbc"
+	case unix.FSCRYPT_MODE_ADIANTUM:
+		return "adiantum"
+	default:
+		return ""
+	}
+}
+
+func isRootAndHomeEncrypted(volumes []EncryptedVolume) (bool, bool) {
+	root, home := false, false
+	homeIsMounted := false
+
+	for _, v := range volumes {
+		switch v.Mountpoint {
+		case "/":
+			root = v.Encrypted
+		case "/home":
+			home = v.Encrypted
+			homeIsMounted = true
+		}
+	}
+
+	if !homeIsMounted {
+		home = root
+	}
+
+	// Users' home folders may be encrypted with fscrypt even if the volume is not
+	if !home {
+		entries, err := os.ReadDir("/home")
+		if err != nil {
+			return root, home
+		}
+
+		found := 0
+		for _, e := range entries {
+			if !e.IsDir() || e.Name() == "lost+found" {
+				continue
+			}
+			if encrypted, _ := getFscryptPolicy(filepath.Join("/home", e.Name())); !encrypted {
+				return root, false
+			}
+			found++
+		}
+		home = found > 0
+	}
+
+	return root, home
+}
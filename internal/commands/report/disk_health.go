@@ -0,0 +1,130 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DISK_HEALTH_OK      = "ok"
+	DISK_HEALTH_WARNING = "warning"
+	DISK_HEALTH_FAILING = "failing"
+	DISK_HEALTH_UNKNOWN = "unknown"
+)
+
+// Temperatures over these values in Celsius are reported as a warning
+const DISK_TEMPERATURE_WARNING = 60
+const NVME_TEMPERATURE_WARNING = 70
+
+// NVMe drives are worn out when the percentage used reaches 100
+const NVME_PERCENTAGE_USED_WARNING = 90
+
+// DiskHealth has the SMART attributes we use to replace drives before they fail,
+// ATA and NVMe attributes are only set for the matching protocol
+type DiskHealth struct {
+	Device             string   `json:"device"`
+	Model              string   `json:"model,omitempty"`
+	Serial             string   `json:"serial,omitempty"`
+	Protocol           string   `json:"protocol,omitempty"`
+	SmartPassed        bool     `json:"smart_passed"`
+	ReallocatedSectors int64    `json:"reallocated_sectors,omitempty"`
+	PendingSectors     int64    `json:"pending_sectors,omitempty"`
+	UncorrectableErrs  int64    `json:"uncorrectable_errors,omitempty"`
+	PowerOnHours       int64    `json:"power_on_hours,omitempty"`
+	PercentageUsed     int64    `json:"percentage_used,omitempty"`
+	MediaErrors        int64    `json:"media_errors,omitempty"`
+	CriticalWarning    int64    `json:"critical_warning,omitempty"`
+	Temperature        int64    `json:"temperature,omitempty"`
+	Status             string   `json:"status"`
+	Reasons            []string `json:"reasons,omitempty"`
+}
+
+// DiskHealthEvent is sent as soon as the status of a disk changes
+type DiskHealthEvent struct {
+	AgentID        string     `json:"agent_id"`
+	PreviousStatus string     `json:"previous_status"`
+	Disk           DiskHealth `json:"disk"`
+	Time           time.Time  `json:"time"`
+}
+
+// Key identifies a disk even if the device name changes after a reboot
+func (d *DiskHealth) Key() string {
+	if d.Serial != "" {
+		return d.Serial
+	}
+	return d.Device
+}
+
+// computeStatus sets the status and the reasons from the attributes
+func (d *DiskHealth) computeStatus() {
+	d.Reasons = []string{}
+	failing := false
+
+	if !d.SmartPassed {
+		failing = true
+		d.Reasons = append(d.Reasons, "SMART overall health test failed")
+	}
+	if d.CriticalWarning != 0 {
+		failing = true
+		d.Reasons = append(d.Reasons, fmt.Sprintf("NVMe critical warning 0x%02x", d.CriticalWarning))
+	}
+	if d.PercentageUsed >= 100 {
+		failing = true
+		d.Reasons = append(d.Reasons, "NVMe endurance exhausted")
+	}
+
+	if d.ReallocatedSectors > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d reallocated sectors", d.ReallocatedSectors))
+	}
+	if d.PendingSectors > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d pending sectors", d.PendingSectors))
+	}
+	if d.UncorrectableErrs > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d uncorrectable errors", d.UncorrectableErrs))
+	}
+	if d.MediaErrors > 0 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d media errors", d.MediaErrors))
+	}
+	if d.PercentageUsed >= NVME_PERCENTAGE_USED_WARNING && d.PercentageUsed < 100 {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("%d%% of NVMe endurance used", d.PercentageUsed))
+	}
+	threshold := int64(DISK_TEMPERATURE_WARNING)
+	if d.Protocol == "NVMe" {
+		threshold = NVME_TEMPERATURE_WARNING
+	}
+	if d.Temperature >= threshold {
+		d.Reasons = append(d.Reasons, fmt.Sprintf("temperature is %d°C", d.Temperature))
+	}
+
+	switch {
+	case failing:
+		d.Status = DISK_HEALTH_FAILING
+	case len(d.Reasons) > 0:
+		d.Status = DISK_HEALTH_WARNING
+	default:
+		d.Status = DISK_HEALTH_OK
+	}
+}
+
+func (r *Report) logDiskHealth() {
+	if len(r.DiskHealth) == 0 {
+		return
+	}
+
+	fmt.Printf("\n** 🩺 Disk Health ***************************************************************************************************\n")
+	for i, d := range r.DiskHealth {
+		fmt.Printf("%-40s |  %s \n", "Disk", d.Device)
+		fmt.Printf("%-40s |  %s \n", "Model", d.Model)
+		fmt.Printf("%-40s |  %s \n", "Serial Number", d.Serial)
+		fmt.Printf("%-40s |  %s \n", "Status", d.Status)
+		fmt.Printf("%-40s |  %d \n", "Power On Hours", d.PowerOnHours)
+		fmt.Printf("%-40s |  %d °C \n", "Temperature", d.Temperature)
+		for _, reason := range d.Reasons {
+			fmt.Printf("%-40s |  %s \n", "Reason", reason)
+		}
+
+		if len(r.DiskHealth) > 1 && i+1 != len(r.DiskHealth) {
+			fmt.Printf("---------------------------------------------------------------------------------------------------------------------\n")
+		}
+	}
+}
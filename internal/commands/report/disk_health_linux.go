@@ -0,0 +1,146 @@
+//go:build linux
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// smartctl exit status is a bitmask, only the first two bits mean that no data could be read
+const SMARTCTL_FATAL_BITS = 0x03
+
+type smartctlScan struct {
+	Devices []struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"`
+	} `json:"devices"`
+}
+
+type smartctlInfo struct {
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	Device       struct {
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	Temperature struct {
+		Current int64 `json:"current"`
+	} `json:"temperature"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		CriticalWarning int64 `json:"critical_warning"`
+		PercentageUsed  int64 `json:"percentage_used"`
+		MediaErrors     int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// ATA attributes IDs
+const (
+	ATA_REALLOCATED_SECTORS   = 5
+	ATA_PENDING_SECTORS       = 197
+	ATA_OFFLINE_UNCORRECTABLE = 198
+)
+
+// GetDisksHealth reads the SMART health of every disk found by smartctl
+func GetDisksHealth() ([]DiskHealth, error) {
+	disks := []DiskHealth{}
+
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		return nil, errors.New("smartctl is not installed")
+	}
+
+	out, err := runSmartctl("--json", "--scan")
+	if err != nil {
+		return nil, err
+	}
+
+	var scan smartctlScan
+	if err := json.Unmarshal(out, &scan); err != nil {
+		return nil, err
+	}
+
+	for _, d := range scan.Devices {
+		out, err := runSmartctl("--json", "--info", "--health", "--attributes", "--device", d.Type, d.Name)
+		if err != nil {
+			log.Printf("[ERROR]: could not read SMART data from %s, reason: %v", d.Name, err)
+			continue
+		}
+
+		var info smartctlInfo
+		if err := json.Unmarshal(out, &info); err != nil {
+			log.Printf("[ERROR]: could not parse SMART data from %s, reason: %v", d.Name, err)
+			continue
+		}
+
+		disks = append(disks, newDiskHealth(d.Name, &info))
+	}
+
+	return disks, nil
+}
+
+func newDiskHealth(device string, info *smartctlInfo) DiskHealth {
+	disk := DiskHealth{
+		Device:       device,
+		Model:        info.ModelName,
+		Serial:       info.SerialNumber,
+		Protocol:     info.Device.Protocol,
+		PowerOnHours: info.PowerOnTime.Hours,
+		Temperature:  info.Temperature.Current,
+	}
+
+	// Some USB bridges don't pass the SMART status through
+	if info.SmartStatus == nil {
+		disk.Status = DISK_HEALTH_UNKNOWN
+		return disk
+	}
+	disk.SmartPassed = info.SmartStatus.Passed
+
+	for _, a := range info.ATASmartAttributes.Table {
+		switch a.ID {
+		case ATA_REALLOCATED_SECTORS:
+			disk.ReallocatedSectors = a.Raw.Value
+		case ATA_PENDING_SECTORS:
+			disk.PendingSectors = a.Raw.Value
+		case ATA_OFFLINE_UNCORRECTABLE:
+			disk.UncorrectableErrs = a.Raw.Value
+		}
+	}
+
+	if info.NVMeHealth != nil {
+		disk.CriticalWarning = info.NVMeHealth.CriticalWarning
+		disk.PercentageUsed = info.NVMeHealth.PercentageUsed
+		disk.MediaErrors = info.NVMeHealth.MediaErrors
+	}
+
+	disk.computeStatus()
+	return disk
+}
+
+func runSmartctl(args ...string) ([]byte, error) {
+	out, err := exec.Command("smartctl", args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if exitErr.ExitCode()&SMARTCTL_FATAL_BITS != 0 {
+			return nil, fmt.Errorf("smartctl exited with status %d", exitErr.ExitCode())
+		}
+		// Other bits tell about the disk health and the JSON output is valid
+		return out, nil
+	}
+	return out, err
+}
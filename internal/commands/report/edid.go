@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const EDID_BLOCK_SIZE = 128
+
+var edidHeader = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// Display descriptor tags
+const (
+	EDID_DESCRIPTOR_SERIAL = 0xff
+	EDID_DESCRIPTOR_NAME   = 0xfc
+)
+
+// EDID is the information we need from the base block of an EDID 1.x structure
+type EDID struct {
+	Manufacturer      string
+	ProductCode       uint16
+	SerialNumber      uint32
+	SerialString      string
+	Name              string
+	WeekOfManufacture int
+	YearOfManufacture int
+	NativeWidth       int
+	NativeHeight      int
+}
+
+// Display adds to the monitor inventory what the console Monitor type doesn't have
+type Display struct {
+	Connector        string `json:"connector,omitempty"`
+	Manufacturer     string `json:"manufacturer"`
+	ProductCode      string `json:"product_code"`
+	Model            string `json:"model,omitempty"`
+	Serial           string `json:"serial,omitempty"`
+	NativeResolution string `json:"native_resolution,omitempty"`
+}
+
+func ParseEDID(data []byte) (*EDID, error) {
+	if len(data) < EDID_BLOCK_SIZE || !bytes.Equal(data[:8], edidHeader) {
+		return nil, errors.New("not a valid EDID")
+	}
+
+	sum := byte(0)
+	for _, b := range data[:EDID_BLOCK_SIZE] {
+		sum += b
+	}
+	if sum != 0 {
+		return nil, errors.New("EDID checksum is not valid")
+	}
+
+	e := EDID{}
+
+	// The PNP ID is made of three letters using 5 bits each, 1 is A
+	id := binary.BigEndian.Uint16(data[8:10])
+	e.Manufacturer = string([]byte{
+		byte((id>>10)&0x1f) + 'A' - 1,
+		byte((id>>5)&0x1f) + 'A' - 1,
+		byte(id&0x1f) + 'A' - 1,
+	})
+
+	e.ProductCode = binary.LittleEndian.Uint16(data[10:12])
+	e.SerialNumber = binary.LittleEndian.Uint32(data[12:16])
+
+	// Week 0xff means that the year is the model year
+	if data[16] > 0 && data[16] <= 54 {
+		e.WeekOfManufacture = int(data[16])
+	}
+	e.YearOfManufacture = int(data[17]) + 1990
+
+	// Four 18 bytes descriptors, the first detailed timing is the preferred (native) mode
+	for offset := 54; offset < 126; offset += 18 {
+		d := data[offset : offset+18]
+
+		if d[0] != 0 || d[1] != 0 {
+			if e.NativeWidth == 0 {
+				e.NativeWidth = int(d[2]) | int(d[4]&0xf0)<<4
+				e.NativeHeight = int(d[5]) | int(d[7]&0xf0)<<4
+			}
+			continue
+		}
+
+		switch d[3] {
+		case EDID_DESCRIPTOR_NAME:
+			e.Name = edidDescriptorText(d[5:])
+		case EDID_DESCRIPTOR_SERIAL:
+			e.SerialString = edidDescriptorText(d[5:])
+		}
+	}
+
+	return &e, nil
+}
+
+// edidDescriptorText ends with a line feed and it's padded with spaces
+func edidDescriptorText(b []byte) string {
+	if i := bytes.IndexByte(b, 0x0a); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (e *EDID) Serial() string {
+	if e.SerialString != "" {
+		return e.SerialString
+	}
+	if e.SerialNumber != 0 {
+		return strconv.FormatUint(uint64(e.SerialNumber), 10)
+	}
+	return ""
+}
+
+func (e *EDID) Model() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return fmt.Sprintf("%s%04X", e.Manufacturer, e.ProductCode)
+}
+
+func (e *EDID) NativeResolution() string {
+	if e.NativeWidth == 0 || e.NativeHeight == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", e.NativeWidth, e.NativeHeight)
+}
@@ -0,0 +1,21 @@
+package report
+
+import (
+	"fmt"
+)
+
+func (r *Report) logFirewall() {
+	if r.Firewall == nil {
+		return
+	}
+
+	fmt.Printf("\n** 🧱 Firewall ******************************************************************************************************\n")
+	fmt.Printf("%-40s |  %s \n", "Backend", r.Firewall.Backend)
+	fmt.Printf("%-40s |  %t \n", "Enabled", r.Firewall.Enabled)
+	fmt.Printf("%-40s |  %s \n", "Default incoming policy", r.Firewall.DefaultPolicies.Incoming)
+	fmt.Printf("%-40s |  %s \n", "Default outgoing policy", r.Firewall.DefaultPolicies.Outgoing)
+	fmt.Printf("%-40s |  %d \n", "Rules", len(r.Firewall.Rules))
+	if r.Firewall.SFTPAccess != "" {
+		fmt.Printf("%-40s |  %s \n", "SFTP port access", r.Firewall.SFTPAccess)
+	}
+}
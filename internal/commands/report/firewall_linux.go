@@ -0,0 +1,31 @@
+//go:build linux
+
+package report
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/scncore/scnorion-agent/internal/commands/firewall"
+)
+
+func (r *Report) getFirewallInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: firewall info has been requested")
+	}
+
+	f, err := firewall.Get()
+	if err != nil {
+		return err
+	}
+
+	// The SFTP server should only be reachable from the console network
+	if port, err := strconv.Atoi(r.SFTPPort); err == nil && !r.SftpServiceDisabled {
+		f.SFTPAccess = f.PortAccess(port, "tcp")
+	}
+
+	r.Firewall = f
+
+	log.Printf("[INFO]: firewall information has been retrieved from %s", f.Backend)
+	return nil
+}
@@ -37,6 +37,8 @@ func (r *Report) getLogicalDisksFromLinux(debug bool) error {
 		return err
 	}
 
+	encryption := DiskEncryption{Volumes: []EncryptedVolume{}}
+
 	for _, m := range mounts {
 		var stat unix.Statfs_t
 		if !strings.Contains(m.Mountpoint, "snap") {
@@ -56,12 +58,26 @@ func (r *Report) getLogicalDisksFromLinux(debug bool) error {
 			myDisk.SizeInUnits = convertBytesToUnits(totalSize)
 			myDisk.RemainingSpaceInUnits = convertBytesToUnits(availableSize)
 			myDisk.Usage = int8(100 - (availableSize * 100 / totalSize))
-			myDisk.BitLockerStatus = "Unsupported"
 			myDisk.VolumeName = m.Source
+
+			volume, err := getVolumeEncryption(m)
+			if err != nil {
+				log.Printf("[ERROR]: could not get encryption status for mountpoint %s, reason: %v", m.Mountpoint, err)
+				myDisk.BitLockerStatus = "Unknown"
+			} else if volume.Encrypted {
+				myDisk.BitLockerStatus = "Encrypted"
+			} else {
+				myDisk.BitLockerStatus = "Unencrypted"
+			}
+			encryption.Volumes = append(encryption.Volumes, volume)
+
 			r.LogicalDisks = append(r.LogicalDisks, myDisk)
 		}
 	}
 
+	encryption.RootEncrypted, encryption.HomeEncrypted = isRootAndHomeEncrypted(encryption.Volumes)
+	r.DiskEncryption = &encryption
+
 	return nil
 }
 
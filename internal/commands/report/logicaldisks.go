@@ -4,6 +4,20 @@ import (
 	"fmt"
 )
 
+type EncryptedVolume struct {
+	Mountpoint string `json:"mountpoint"`
+	Device     string `json:"device"`
+	Encrypted  bool   `json:"encrypted"`
+	Method     string `json:"method,omitempty"`
+	Cipher     string `json:"cipher,omitempty"`
+}
+
+type DiskEncryption struct {
+	Volumes       []EncryptedVolume `json:"volumes"`
+	RootEncrypted bool              `json:"root_encrypted"`
+	HomeEncrypted bool              `json:"home_encrypted"`
+}
+
 func (r *Report) logLogicalDisks() {
 	fmt.Printf("\n** 💾 Logical Disks *************************************************************************************************\n")
 	if len(r.LogicalDisks) > 0 {
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	LOGIN_EVENT_LOGIN  = "login"
+	LOGIN_EVENT_LOGOUT = "logout"
+	LOGIN_EVENT_FAILED = "failed"
+)
+
+// The first report only has the events of the last days, wtmp can go back months
+const LOGIN_HISTORY_FIRST_RUN_DAYS = 30
+
+// MAX_LOGIN_EVENTS keeps the report small on machines with many failed attempts,
+// the oldest events are dropped
+const MAX_LOGIN_EVENTS = 1000
+
+const LOGIN_CURSOR_FILE = "login_history.json"
+
+type LoginEvent struct {
+	Event           string     `json:"event"`
+	User            string     `json:"user"`
+	Host            string     `json:"host,omitempty"`
+	TTY             string     `json:"tty,omitempty"`
+	Seat            string     `json:"seat,omitempty"`
+	SessionType     string     `json:"session_type,omitempty"`
+	Time            time.Time  `json:"time"`
+	End             *time.Time `json:"end,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds,omitempty"`
+	PID             int32      `json:"-"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	User       string    `json:"user"`
+	Seat       string    `json:"seat,omitempty"`
+	TTY        string    `json:"tty,omitempty"`
+	Type       string    `json:"type"`
+	Class      string    `json:"class,omitempty"`
+	Remote     bool      `json:"remote"`
+	RemoteHost string    `json:"remote_host,omitempty"`
+	Since      time.Time `json:"since"`
+	Leader     int32     `json:"-"`
+}
+
+// LoginCursor tells where the previous report stopped reading the login files,
+// open sessions are kept so their logout can be reported later
+type LoginCursor struct {
+	ReadAt       time.Time             `json:"read_at"`
+	WtmpOffset   int64                 `json:"wtmp_offset"`
+	WtmpInode    uint64                `json:"wtmp_inode"`
+	BtmpOffset   int64                 `json:"btmp_offset"`
+	BtmpInode    uint64                `json:"btmp_inode"`
+	OpenSessions map[string]LoginEvent `json:"open_sessions"`
+}
+
+type LoginHistory struct {
+	Events         []LoginEvent `json:"events"`
+	ActiveSessions []Session    `json:"active_sessions"`
+	Cursor         *LoginCursor `json:"-"`
+}
+
+// Reports can run concurrently (scheduled, requested, after a task...),
+// the cursor file must not go back to an older position
+var loginCursorMutex sync.Mutex
+
+func loginCursorPath() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(ex), LOGIN_CURSOR_FILE), nil
+}
+
+// LoadLoginCursor returns nil if the login history has never been reported
+func LoadLoginCursor() (*LoginCursor, error) {
+	path, err := loginCursorPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := LoginCursor{}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SaveLoginCursor must be called once the report has been sent so the events are not lost,
+// a cursor read before the stored one is discarded. Offsets can't be compared as wtmp may be rotated
+func SaveLoginCursor(cursor *LoginCursor) error {
+	if cursor == nil {
+		return nil
+	}
+
+	loginCursorMutex.Lock()
+	defer loginCursorMutex.Unlock()
+
+	stored, err := LoadLoginCursor()
+	if err != nil {
+		log.Printf("[ERROR]: could not read the login history cursor, reason: %v", err)
+	}
+	if stored != nil && !cursor.ReadAt.After(stored.ReadAt) {
+		log.Println("[INFO]: a newer login history cursor has already been saved")
+		return nil
+	}
+
+	path, err := loginCursorPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func (r *Report) logLoginHistory() {
+	if r.LoginHistory == nil {
+		return
+	}
+
+	fmt.Printf("\n** 🔑 Login History *************************************************************************************************\n")
+	for _, s := range r.LoginHistory.ActiveSessions {
+		fmt.Printf("%-40s |  %s %s %s since %s \n", "Active session "+s.ID, s.User, s.Type, s.RemoteHost, s.Since.Format(time.DateTime))
+	}
+	for _, e := range r.LoginHistory.Events {
+		fmt.Printf("%-40s |  %s %s %s %s \n", e.Time.Format(time.DateTime), e.Event, e.User, e.Host, e.SessionType)
+	}
+}
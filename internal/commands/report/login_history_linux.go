@@ -0,0 +1,263 @@
+//go:build linux
+
+package report
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// loginctl properties of a session
+var sessionProperties = []string{"Id", "Name", "Seat", "TTY", "Type", "Class", "Remote", "RemoteHost", "Leader", "Timestamp"}
+
+func (r *Report) getLoginHistoryInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: login history info has been requested")
+	}
+
+	cursor, err := LoadLoginCursor()
+	if err != nil {
+		log.Printf("[ERROR]: could not read the login history cursor, reason: %v", err)
+	}
+	firstRun := cursor == nil
+	if firstRun {
+		cursor = &LoginCursor{}
+	}
+	if cursor.OpenSessions == nil {
+		cursor.OpenSessions = map[string]LoginEvent{}
+	}
+	cursor.ReadAt = time.Now()
+
+	history := LoginHistory{Events: []LoginEvent{}, ActiveSessions: getActiveSessions()}
+
+	// The session leader of a login is the process in the utmp record
+	sessionsByLeader := map[int32]Session{}
+	for _, s := range history.ActiveSessions {
+		if s.Leader > 0 {
+			sessionsByLeader[s.Leader] = s
+		}
+	}
+
+	records, offset, inode, err := readNewUtmpRecords(WTMP_PATH, cursor.WtmpOffset, cursor.WtmpInode)
+	if err != nil {
+		log.Printf("[ERROR]: could not read %s, reason: %v", WTMP_PATH, err)
+	} else {
+		cursor.WtmpOffset, cursor.WtmpInode = offset, inode
+		history.Events = append(history.Events, wtmpEvents(records, cursor.OpenSessions, sessionsByLeader)...)
+	}
+
+	// btmp can only be read by root
+	records, offset, inode, err = readNewUtmpRecords(BTMP_PATH, cursor.BtmpOffset, cursor.BtmpInode)
+	if err != nil {
+		log.Printf("[INFO]: could not read %s, failed logins won't be reported, reason: %v", BTMP_PATH, err)
+	} else {
+		cursor.BtmpOffset, cursor.BtmpInode = offset, inode
+		for _, rec := range records {
+			history.Events = append(history.Events, LoginEvent{
+				Event:       LOGIN_EVENT_FAILED,
+				User:        rec.User,
+				Host:        rec.Host,
+				TTY:         rec.Line,
+				SessionType: guessSessionType(rec.Line, rec.Host),
+				Time:        rec.Time,
+			})
+		}
+	}
+
+	slices.SortStableFunc(history.Events, func(a, b LoginEvent) int { return a.Time.Compare(b.Time) })
+
+	if firstRun {
+		since := time.Now().AddDate(0, 0, -LOGIN_HISTORY_FIRST_RUN_DAYS)
+		history.Events = slices.DeleteFunc(history.Events, func(e LoginEvent) bool { return e.Time.Before(since) })
+	}
+	if len(history.Events) > MAX_LOGIN_EVENTS {
+		history.Events = history.Events[len(history.Events)-MAX_LOGIN_EVENTS:]
+	}
+
+	history.Cursor = cursor
+	r.LoginHistory = &history
+
+	log.Printf("[INFO]: login history information has been retrieved")
+	return nil
+}
+
+// wtmpEvents pairs USER_PROCESS and DEAD_PROCESS records by terminal line,
+// sessions still open are kept in openSessions for the next report
+func wtmpEvents(records []utmpRecord, openSessions map[string]LoginEvent, sessionsByLeader map[int32]Session) []LoginEvent {
+	events := []LoginEvent{}
+
+	closeSession := func(line string, end time.Time) {
+		open, ok := openSessions[line]
+		if !ok {
+			return
+		}
+		delete(openSessions, line)
+
+		open.Event = LOGIN_EVENT_LOGOUT
+		open.End = &end
+		open.DurationSeconds = int64(end.Sub(open.Time).Seconds())
+		events = append(events, open)
+	}
+
+	for _, rec := range records {
+		switch rec.Type {
+		case UTMP_USER_PROCESS:
+			if rec.User == "" {
+				continue
+			}
+			// A new login on the same line means we missed the logout
+			closeSession(rec.Line, rec.Time)
+
+			e := LoginEvent{
+				Event:       LOGIN_EVENT_LOGIN,
+				User:        rec.User,
+				Host:        rec.Host,
+				TTY:         rec.Line,
+				SessionType: guessSessionType(rec.Line, rec.Host),
+				Time:        rec.Time,
+				PID:         rec.PID,
+			}
+			if s, ok := sessionsByLeader[rec.PID]; ok {
+				e.Seat = s.Seat
+				e.SessionType = s.Type
+			}
+			events = append(events, e)
+			openSessions[rec.Line] = e
+		case UTMP_DEAD_PROCESS:
+			closeSession(rec.Line, rec.Time)
+		case UTMP_BOOT_TIME:
+			// Sessions that were open end when the machine restarts
+			for line := range openSessions {
+				closeSession(line, rec.Time)
+			}
+		}
+	}
+
+	return events
+}
+
+func readNewUtmpRecords(path string, offset int64, inode uint64) ([]utmpRecord, int64, uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, offset, inode, err
+	}
+
+	// A different inode means the file has been rotated
+	currentInode := uint64(0)
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		currentInode = uint64(st.Ino)
+	}
+	if currentInode != inode {
+		offset = 0
+	}
+
+	records, offset, err := readUtmpFile(path, offset)
+	return records, offset, currentInode, err
+}
+
+func guessSessionType(line, host string) string {
+	switch {
+	case line == "ssh:notty" || (strings.HasPrefix(line, "pts/") && host != "" && !strings.HasPrefix(host, ":")):
+		return "ssh"
+	case strings.HasPrefix(line, ":") || strings.HasPrefix(host, ":"):
+		return "x11"
+	case strings.HasPrefix(line, "tty"):
+		return "tty"
+	default:
+		return "unspecified"
+	}
+}
+
+type loginctlSession struct {
+	Session string `json:"session"`
+}
+
+// getActiveSessions asks logind for the current sessions
+func getActiveSessions() []Session {
+	sessions := []Session{}
+
+	ids := []string{}
+	out, err := exec.Command("loginctl", "list-sessions", "--no-pager", "--output=json").Output()
+	if err == nil {
+		list := []loginctlSession{}
+		if err := json.Unmarshal(out, &list); err == nil {
+			for _, s := range list {
+				ids = append(ids, s.Session)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		out, err = exec.Command("loginctl", "list-sessions", "--no-pager", "--no-legend").Output()
+		if err != nil {
+			return sessions
+		}
+		for line := range strings.SplitSeq(string(out), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				ids = append(ids, fields[0])
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return sessions
+	}
+
+	args := append([]string{"show-session", "--no-pager", "--property=" + strings.Join(sessionProperties, ",")}, ids...)
+	out, err = exec.Command("loginctl", args...).Output()
+	if err != nil {
+		return sessions
+	}
+
+	for block := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n\n") {
+		s := Session{}
+		for line := range strings.SplitSeq(block, "\n") {
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				continue
+			}
+			switch key {
+			case "Id":
+				s.ID = value
+			case "Name":
+				s.User = value
+			case "Seat":
+				s.Seat = value
+			case "TTY":
+				s.TTY = value
+			case "Type":
+				s.Type = value
+			case "Class":
+				s.Class = value
+			case "Remote":
+				s.Remote = value == "yes"
+			case "RemoteHost":
+				s.RemoteHost = value
+			case "Leader":
+				if pid, err := strconv.ParseInt(value, 10, 32); err == nil {
+					s.Leader = int32(pid)
+				}
+			case "Timestamp":
+				// e.g Mon 2024-05-06 09:12:01 CEST
+				if fields := strings.Fields(value); len(fields) >= 3 {
+					if t, err := time.ParseInLocation(time.DateTime, fields[1]+" "+fields[2], time.Local); err == nil {
+						s.Since = t
+					}
+				}
+			}
+		}
+		if s.Remote && s.Type == "tty" {
+			s.Type = "ssh"
+		}
+		if s.ID != "" {
+			sessions = append(sessions, s)
+		}
+	}
+
+	return sessions
+}
@@ -11,6 +11,9 @@ func (r *Report) logMonitors() {
 			fmt.Printf("%-40s |  %s \n", "Manufacturer", v.Manufacturer)
 			fmt.Printf("%-40s |  %s \n", "Model", v.Model)
 			fmt.Printf("%-40s |  %s \n", "Serial number", v.Serial)
+			if i < len(r.Displays) && r.Displays[i].NativeResolution != "" {
+				fmt.Printf("%-40s |  %s \n", "Native resolution", r.Displays[i].NativeResolution)
+			}
 			if len(r.Monitors) > 1 && i+1 != len(r.Monitors) {
 				fmt.Printf("---------------------------------------------------------------------------------------------------------------------\n")
 			}
@@ -4,68 +4,79 @@ package report
 
 import (
 	"log"
-	"os/exec"
-	"regexp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	scnorion_nats "github.com/scncore/nats"
 )
 
+const DRM_DIR = "/sys/class/drm"
+
+// getMonitorsInfo parses the EDID that the kernel exposes for every connector,
+// it works without a graphical session so docked headless machines are reported too
 func (r *Report) getMonitorsInfo(debug bool) error {
 	r.Monitors = []scnorion_nats.Monitor{}
+	r.Displays = []Display{}
 
 	if debug {
 		log.Println("[DEBUG]: monitors info has been requested")
 	}
 
-	out, err := exec.Command("hwinfo", "--monitor").Output()
+	paths, err := filepath.Glob(filepath.Join(DRM_DIR, "card*-*", "edid"))
 	if err != nil {
 		return err
 	}
 
-	reg := regexp.MustCompile(`Serial ID: "\s*(.*?)\s*"`)
-	matches := reg.FindAllStringSubmatch(string(out), -1)
-	for _, v := range matches {
-		myMonitor := scnorion_nats.Monitor{}
-		if v[1] == "" || v[1] == "0" {
-			myMonitor.Serial = "Unknown"
-		} else {
-			myMonitor.Serial = v[1]
-		}
-		r.Monitors = append(r.Monitors, myMonitor)
-	}
+	// The same monitor can be seen by two GPUs (e.g hybrid graphics laptops)
+	seen := map[string]bool{}
 
-	reg = regexp.MustCompile(`Model: "\s*(.*?)\s*"`)
-	matches = reg.FindAllStringSubmatch(string(out), -1)
-	for i, v := range matches {
-		if len(r.Monitors) > i {
-			r.Monitors[i].Model = v[1]
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil || len(data) == 0 {
+			// Disconnected connectors have an empty EDID
+			continue
 		}
-	}
 
-	reg = regexp.MustCompile(`Vendor: \s*(.*?)\s* `)
-	matches = reg.FindAllStringSubmatch(string(out), -1)
-	for i, v := range matches {
-		if len(r.Monitors) > i {
-			r.Monitors[i].Manufacturer = v[1]
+		connector := filepath.Base(filepath.Dir(p))
+		e, err := ParseEDID(data)
+		if err != nil {
+			log.Printf("[ERROR]: could not parse EDID from %s, reason: %v", connector, err)
+			continue
 		}
-	}
 
-	reg = regexp.MustCompile(`Week of Manufacture: \s*(.*?)\s* `)
-	matches = reg.FindAllStringSubmatch(string(out), -1)
-	for i, v := range matches {
-		if len(r.Monitors) > i {
-			r.Monitors[i].WeekOfManufacture = v[1]
+		key := e.Manufacturer + strconv.Itoa(int(e.ProductCode)) + e.Serial()
+		if seen[key] {
+			continue
 		}
-	}
+		seen[key] = true
 
-	reg = regexp.MustCompile(`Year of Manufacture: \s*(.*?)\s* `)
-	matches = reg.FindAllStringSubmatch(string(out), -1)
-	for i, v := range matches {
-		if len(r.Monitors) > i {
-			r.Monitors[i].YearOfManufacture = v[1]
+		serial := e.Serial()
+		if serial == "" {
+			serial = "Unknown"
 		}
+
+		r.Monitors = append(r.Monitors, scnorion_nats.Monitor{
+			Manufacturer:      e.Manufacturer,
+			Model:             e.Model(),
+			Serial:            serial,
+			WeekOfManufacture: strconv.Itoa(e.WeekOfManufacture),
+			YearOfManufacture: strconv.Itoa(e.YearOfManufacture),
+		})
+
+		// cardN-DP-1 -> DP-1
+		_, connectorName, _ := strings.Cut(connector, "-")
+		r.Displays = append(r.Displays, Display{
+			Connector:        connectorName,
+			Manufacturer:     e.Manufacturer,
+			ProductCode:      strconv.FormatUint(uint64(e.ProductCode), 16),
+			Model:            e.Model(),
+			Serial:           serial,
+			NativeResolution: e.NativeResolution(),
+		})
 	}
 
-	log.Printf("[INFO]: monitors information has been retrieved from Linux hwinfo")
+	log.Printf("[INFO]: monitors information has been retrieved from EDID")
 	return nil
 }
@@ -33,6 +33,15 @@ func (r *Report) getNetworkAdaptersInfo(debug bool) error {
 	return nil
 }
 
+// GetActiveNetworkAdapters returns the active network adapters as they're reported to the console
+func GetActiveNetworkAdapters() ([]scnorion_nats.NetworkAdapter, error) {
+	r := Report{}
+	if err := r.getNetworkAdaptersFromLinux(); err != nil {
+		return nil, err
+	}
+	return r.NetworkAdapters, nil
+}
+
 func (r *Report) getNetworkAdaptersFromLinux() error {
 	var si sysinfo.SysInfo
 
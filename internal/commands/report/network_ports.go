@@ -0,0 +1,48 @@
+package report
+
+import (
+	"fmt"
+)
+
+// MAX_CONNECTIONS limits the established connections snapshot, busy servers can have thousands
+const MAX_CONNECTIONS = 500
+
+type Socket struct {
+	Protocol      string `json:"protocol"`
+	LocalAddress  string `json:"local_address"`
+	LocalPort     int    `json:"local_port"`
+	RemoteAddress string `json:"remote_address,omitempty"`
+	RemotePort    int    `json:"remote_port,omitempty"`
+	State         string `json:"state"`
+	PID           int    `json:"pid,omitempty"`
+	Process       string `json:"process,omitempty"`
+	User          string `json:"user,omitempty"`
+	// Agent is true for the sockets opened by the agent itself e.g the SFTP server or the VNC proxy
+	Agent bool `json:"agent"`
+}
+
+type NetworkSockets struct {
+	Listening   []Socket `json:"listening"`
+	Established []Socket `json:"established,omitempty"`
+}
+
+func (r *Report) logNetworkSockets() {
+	if r.NetworkSockets == nil {
+		return
+	}
+
+	fmt.Printf("\n** 🔌 Listening Ports *********************************************************************************************\n")
+	if len(r.NetworkSockets.Listening) == 0 {
+		fmt.Printf("%-40s\n", "No listening ports found")
+	}
+	for _, s := range r.NetworkSockets.Listening {
+		owner := s.Process
+		if s.Agent {
+			owner += " (agent)"
+		}
+		fmt.Printf("%-40s |  %s %s \n", fmt.Sprintf("%s %s:%d", s.Protocol, s.LocalAddress, s.LocalPort), owner, s.User)
+	}
+	if r.NetworkSockets.Established != nil {
+		fmt.Printf("%-40s |  %d \n", "Established connections", len(r.NetworkSockets.Established))
+	}
+}
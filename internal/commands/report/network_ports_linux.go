@@ -0,0 +1,232 @@
+//go:build linux
+
+package report
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"log"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// States used by the kernel in /proc/net/tcp, see include/net/tcp_states.h
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+type procSocket struct {
+	Socket
+	uid   string
+	inode string
+}
+
+type socketOwner struct {
+	pid     int
+	process string
+}
+
+func (r *Report) getNetworkSocketsInfo(debug bool, established bool) error {
+	if debug {
+		log.Println("[DEBUG]: listening ports info has been requested")
+	}
+
+	sockets := []procSocket{}
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		s, err := readProcNet(protocol)
+		if err != nil {
+			log.Printf("[INFO]: could not read /proc/net/%s, reason: %v", protocol, err)
+			continue
+		}
+		sockets = append(sockets, s...)
+	}
+
+	owners := socketOwners()
+	agentPorts := map[int]bool{}
+	for _, p := range []string{r.SFTPPort, r.VNCProxyPort} {
+		if port, err := strconv.Atoi(p); err == nil {
+			agentPorts[port] = true
+		}
+	}
+	agentPID := os.Getpid()
+
+	users := map[string]string{}
+	lookupUser := func(uid string) string {
+		if name, ok := users[uid]; ok {
+			return name
+		}
+		name := uid
+		if u, err := user.LookupId(uid); err == nil {
+			name = u.Username
+		}
+		users[uid] = name
+		return name
+	}
+
+	result := NetworkSockets{Listening: []Socket{}}
+	if established {
+		result.Established = []Socket{}
+	}
+
+	for _, s := range sockets {
+		// UDP has no listen state, a socket not connected to a remote peer is waiting for datagrams
+		listening := s.State == "LISTEN" || (strings.HasPrefix(s.Protocol, "udp") && s.RemotePort == 0)
+		if !listening && (!established || s.State != "ESTABLISHED") {
+			continue
+		}
+
+		if o, ok := owners[s.inode]; ok {
+			s.PID = o.pid
+			s.Process = o.process
+		}
+		s.User = lookupUser(s.uid)
+		s.Agent = s.PID == agentPID || (listening && agentPorts[s.LocalPort] && s.PID == 0)
+
+		if listening {
+			s.State = "LISTEN"
+			result.Listening = append(result.Listening, s.Socket)
+		} else if len(result.Established) < MAX_CONNECTIONS {
+			result.Established = append(result.Established, s.Socket)
+		}
+	}
+
+	slices.SortFunc(result.Listening, func(a, b Socket) int {
+		if a.LocalPort != b.LocalPort {
+			return a.LocalPort - b.LocalPort
+		}
+		return strings.Compare(a.Protocol, b.Protocol)
+	})
+
+	r.NetworkSockets = &result
+
+	log.Printf("[INFO]: listening ports information has been retrieved")
+	return nil
+}
+
+// readProcNet parses the socket table of a protocol, the first line is the header
+func readProcNet(protocol string) ([]procSocket, error) {
+	data, err := os.ReadFile(filepath.Join("/proc/net", protocol))
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := []procSocket{}
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+
+		localAddress, localPort, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		remoteAddress, remotePort, err := parseProcNetAddress(fields[2])
+		if err != nil {
+			continue
+		}
+
+		s := procSocket{
+			Socket: Socket{
+				Protocol:     protocol,
+				LocalAddress: localAddress,
+				LocalPort:    localPort,
+				State:        tcpStates[fields[3]],
+			},
+			uid:   fields[7],
+			inode: fields[9],
+		}
+		if remotePort != 0 {
+			s.RemoteAddress = remoteAddress
+			s.RemotePort = remotePort
+		}
+		sockets = append(sockets, s)
+	}
+	return sockets, nil
+}
+
+// parseProcNetAddress decodes ADDRESS:PORT in hex, the address is stored
+// as 32 bits words in host byte order e.g 0100007F:0016 -> 127.0.0.1 22
+func parseProcNetAddress(s string) (string, int, error) {
+	addr, port, _ := strings.Cut(s, ":")
+
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return "", 0, err
+	}
+
+	b, err := hex.DecodeString(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return "", 0, strconv.ErrSyntax
+	}
+
+	ip := make(net.IP, len(b))
+	for i := 0; i < len(b); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(b[i:]))
+	}
+
+	return ip.String(), int(p), nil
+}
+
+// socketOwners maps socket inodes to the processes having them open, we need
+// to be root to read the descriptors of processes owned by other users
+func socketOwners() map[string]socketOwner {
+	owners := map[string]socketOwner{}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return owners
+	}
+
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join("/proc", e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		process := ""
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode := strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")
+			if _, ok := owners[inode]; ok {
+				continue
+			}
+			if process == "" {
+				if comm, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm")); err == nil {
+					process = strings.TrimSpace(string(comm))
+				}
+			}
+			owners[inode] = socketOwner{pid: pid, process: process}
+		}
+	}
+
+	return owners
+}
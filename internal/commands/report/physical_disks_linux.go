@@ -51,6 +51,13 @@ func (r *Report) getPhysicalDisksInfo(debug bool) error {
 		}
 	}
 
+	// SMART health is optional, smartctl may not be installed
+	if health, err := GetDisksHealth(); err != nil {
+		log.Printf("[INFO]: could not get disks health, reason: %v", err)
+	} else {
+		r.DiskHealth = health
+	}
+
 	if debug {
 		log.Println("[DEBUG]: physical disk info retrieval finished")
 	}
@@ -4,10 +4,31 @@ import (
 	"fmt"
 
 	scnorion_nats "github.com/scncore/nats"
+	"github.com/scncore/scnorion-agent/internal/commands/compliance"
+	"github.com/scncore/scnorion-agent/internal/commands/firewall"
+	"github.com/scncore/scnorion-agent/internal/commands/services"
+	"github.com/scncore/scnorion-agent/internal/commands/telemetry"
 )
 
 type Report struct {
 	scnorion_nats.AgentReport
+	SecurityProducts  []SecurityProduct            `json:"security_products,omitempty"`
+	DiskEncryption    *DiskEncryption              `json:"disk_encryption,omitempty"`
+	PendingUpdateList *PendingUpdates              `json:"pending_update_list,omitempty"`
+	Batteries         []Battery                    `json:"batteries,omitempty"`
+	Devices           []Device                     `json:"devices,omitempty"`
+	GPUs              []GPU                        `json:"gpus,omitempty"`
+	DiskHealth        []DiskHealth                 `json:"disk_health,omitempty"`
+	Displays          []Display                    `json:"displays,omitempty"`
+	ComputerDetails   *ComputerDetails             `json:"computer_details,omitempty"`
+	Telemetry         *telemetry.Telemetry         `json:"telemetry,omitempty"`
+	Services          []services.Unit              `json:"services,omitempty"`
+	FailedUnits       []services.Unit              `json:"failed_units,omitempty"`
+	Accounts          *Accounts                    `json:"accounts,omitempty"`
+	LoginHistory      *LoginHistory                `json:"login_history,omitempty"`
+	NetworkSockets    *NetworkSockets              `json:"network_sockets,omitempty"`
+	Firewall          *firewall.Firewall           `json:"firewall,omitempty"`
+	Compliance        *compliance.ComplianceResult `json:"compliance,omitempty"`
 }
 
 func (r *Report) logOS() {
@@ -28,14 +49,23 @@ func (r *Report) Print() {
 	fmt.Printf("%-40s |  %s\n", "Operating System", r.OS)
 
 	r.logComputer()
+	r.logBatteries()
 	r.logOS()
 	r.logPhysicalDisks()
+	r.logDiskHealth()
 	r.logLogicalDisks()
 	r.logMonitors()
+	r.logDevices()
 	r.logPrinters()
 	r.logShares()
 	r.logAntivirus()
 	r.logSystemUpdate()
+	r.logServices()
+	r.logAccounts()
+	r.logLoginHistory()
+	r.logNetworkSockets()
+	r.logFirewall()
+	r.logCompliance()
 	r.logNetworkAdapters()
 	r.logApplications()
 }
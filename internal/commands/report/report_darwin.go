@@ -15,7 +15,7 @@ import (
 	scnorion_nats "github.com/scncore/nats"
 )
 
-func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAddress string, sftpDisabled, remoteAssistanceDisabled bool, tenantID string, siteID string) (*Report, error) {
+func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAddress string, sftpDisabled, remoteAssistanceDisabled bool, tenantID string, siteID string, reportConnections bool) (*Report, error) {
 	var wg sync.WaitGroup
 	var err error
 
@@ -174,6 +174,14 @@ func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAd
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getBatteriesInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get batteries information: %v", err)
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -15,7 +15,7 @@ import (
 	"github.com/zcalusic/sysinfo"
 )
 
-func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAddress string, sftpDisabled, remoteAssistanceDisabled bool, tenantID string, siteID string) (*Report, error) {
+func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAddress string, sftpDisabled, remoteAssistanceDisabled bool, tenantID string, siteID string, reportConnections bool) (*Report, error) {
 	var si sysinfo.SysInfo
 	var wg sync.WaitGroup
 	var err error
@@ -180,6 +180,70 @@ func RunReport(agentId string, enabled, debug bool, vncProxyPort, sftpPort, ipAd
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getAccountsInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get local accounts information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getLoginHistoryInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get login history information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getNetworkSocketsInfo(debug, reportConnections); err != nil {
+			log.Printf("[ERROR]: could not get listening ports information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getFirewallInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get firewall information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getComplianceInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not evaluate the compliance policy: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getServicesInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get services information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getDevicesInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get devices information: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := report.getBatteriesInfo(debug); err != nil {
+			log.Printf("[ERROR]: could not get batteries information: %v", err)
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
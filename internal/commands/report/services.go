@@ -0,0 +1,21 @@
+package report
+
+import (
+	"fmt"
+)
+
+func (r *Report) logServices() {
+	if r.Services == nil && r.FailedUnits == nil {
+		return
+	}
+
+	fmt.Printf("\n** ⚙️  Services ******************************************************************************************************\n")
+	fmt.Printf("%-40s |  %d \n", "Services", len(r.Services))
+	if len(r.FailedUnits) == 0 {
+		fmt.Printf("%-40s\n", "No failed units found")
+		return
+	}
+	for _, u := range r.FailedUnits {
+		fmt.Printf("%-40s |  %s (%s) \n", "Failed unit", u.Name, u.Result)
+	}
+}
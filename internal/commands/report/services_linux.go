@@ -0,0 +1,30 @@
+//go:build linux
+
+package report
+
+import (
+	"log"
+
+	"github.com/scncore/scnorion-agent/internal/commands/services"
+)
+
+func (r *Report) getServicesInfo(debug bool) error {
+	if debug {
+		log.Println("[DEBUG]: services info has been requested")
+	}
+
+	units, err := services.List()
+	if err != nil {
+		return err
+	}
+	r.Services = units
+
+	failed, err := services.ListFailed()
+	if err != nil {
+		return err
+	}
+	r.FailedUnits = failed
+
+	log.Printf("[INFO]: services information has been retrieved from systemd")
+	return nil
+}
@@ -0,0 +1,185 @@
+package report
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// SMBIOS structure types
+const (
+	SMBIOS_BIOS          = 0
+	SMBIOS_SYSTEM        = 1
+	SMBIOS_CHASSIS       = 3
+	SMBIOS_MEMORY_DEVICE = 17
+	SMBIOS_END_OF_TABLE  = 127
+)
+
+// SMBIOSStructure is a formatted area followed by its strings, string fields
+// in the formatted area are 1-based indexes into Strings
+type SMBIOSStructure struct {
+	Type      byte
+	Handle    uint16
+	Formatted []byte
+	Strings   []string
+}
+
+// ComputerDetails adds to the computer inventory what the console Computer type doesn't have
+type ComputerDetails struct {
+	SKU         string `json:"sku,omitempty"`
+	Family      string `json:"family,omitempty"`
+	ChassisType string `json:"chassis_type,omitempty"`
+	AssetTag    string `json:"asset_tag,omitempty"`
+	BIOSVendor  string `json:"bios_vendor,omitempty"`
+	BIOSVersion string `json:"bios_version,omitempty"`
+	BIOSDate    string `json:"bios_date,omitempty"`
+}
+
+// Placeholders that vendors leave in the strings
+var smbiosPlaceholders = []string{
+	"", "not specified", "not available", "to be filled by o.e.m.", "default string", "none", "unknown",
+	"system serial number", "system product name", "system manufacturer", "chassis serial number",
+	"asset-1234567", "no asset tag", "no dimm", "empty", "0000000", "00000000", "123456789",
+}
+
+var chassisTypes = []string{
+	"", "Other", "Unknown", "Desktop", "Low Profile Desktop", "Pizza Box", "Mini Tower", "Tower", "Portable",
+	"Laptop", "Notebook", "Hand Held", "Docking Station", "All in One", "Sub Notebook", "Space-saving",
+	"Lunch Box", "Main Server Chassis", "Expansion Chassis", "SubChassis", "Bus Expansion Chassis",
+	"Peripheral Chassis", "RAID Chassis", "Rack Mount Chassis", "Sealed-case PC", "Multi-system Chassis",
+	"Compact PCI", "Advanced TCA", "Blade", "Blade Enclosure", "Tablet", "Convertible", "Detachable",
+	"IoT Gateway", "Embedded PC", "Mini PC", "Stick PC",
+}
+
+var memoryTypes = map[byte]string{
+	0x01: "Other", 0x02: "Unknown", 0x03: "DRAM", 0x04: "EDRAM", 0x05: "VRAM", 0x06: "SRAM", 0x07: "RAM",
+	0x08: "ROM", 0x09: "Flash", 0x0a: "EEPROM", 0x0b: "FEPROM", 0x0c: "EPROM", 0x0d: "CDRAM", 0x0e: "3DRAM",
+	0x0f: "SDRAM", 0x10: "SGRAM", 0x11: "RDRAM", 0x12: "DDR", 0x13: "DDR2", 0x14: "DDR2 FB-DIMM",
+	0x18: "DDR3", 0x19: "FBD2", 0x1a: "DDR4", 0x1b: "LPDDR", 0x1c: "LPDDR2", 0x1d: "LPDDR3", 0x1e: "LPDDR4",
+	0x1f: "Logical non-volatile device", 0x20: "HBM", 0x21: "HBM2", 0x22: "DDR5", 0x23: "LPDDR5", 0x24: "HBM3",
+}
+
+// ParseSMBIOS splits the raw structure table into structures
+func ParseSMBIOS(table []byte) ([]SMBIOSStructure, error) {
+	structures := []SMBIOSStructure{}
+
+	for offset := 0; offset+4 <= len(table); {
+		length := int(table[offset+1])
+		if length < 4 || offset+length > len(table) {
+			return structures, fmt.Errorf("SMBIOS structure at offset %d is not valid", offset)
+		}
+
+		s := SMBIOSStructure{
+			Type:      table[offset],
+			Handle:    binary.LittleEndian.Uint16(table[offset+2 : offset+4]),
+			Formatted: table[offset : offset+length],
+		}
+
+		// The string set ends with two null bytes
+		end := offset + length
+		for end+1 < len(table) && (table[end] != 0 || table[end+1] != 0) {
+			end++
+		}
+		if end+1 >= len(table) {
+			return structures, fmt.Errorf("SMBIOS structure at offset %d has no end", offset)
+		}
+		for str := range strings.SplitSeq(string(table[offset+length:end]), "\x00") {
+			if str != "" {
+				s.Strings = append(s.Strings, str)
+			}
+		}
+
+		structures = append(structures, s)
+		if s.Type == SMBIOS_END_OF_TABLE {
+			break
+		}
+		offset = end + 2
+	}
+
+	return structures, nil
+}
+
+func (s *SMBIOSStructure) byteAt(offset int) byte {
+	if offset >= len(s.Formatted) {
+		return 0
+	}
+	return s.Formatted[offset]
+}
+
+func (s *SMBIOSStructure) wordAt(offset int) uint16 {
+	if offset+2 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(s.Formatted[offset : offset+2])
+}
+
+func (s *SMBIOSStructure) dwordAt(offset int) uint32 {
+	if offset+4 > len(s.Formatted) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(s.Formatted[offset : offset+4])
+}
+
+// stringAt returns the string referenced at offset or an empty string for vendor placeholders
+func (s *SMBIOSStructure) stringAt(offset int) string {
+	index := int(s.byteAt(offset))
+	if index == 0 || index > len(s.Strings) {
+		return ""
+	}
+
+	value := strings.TrimSpace(s.Strings[index-1])
+	for _, p := range smbiosPlaceholders {
+		if strings.EqualFold(value, p) {
+			return ""
+		}
+	}
+	return value
+}
+
+func chassisTypeName(t byte) string {
+	// The high bit is the chassis lock
+	t &= 0x7f
+	if int(t) < len(chassisTypes) {
+		return chassisTypes[t]
+	}
+	return "Unknown"
+}
+
+// memoryDeviceSize returns the module size in MB, 0 means the slot is empty
+func memoryDeviceSize(s *SMBIOSStructure) uint64 {
+	size := s.wordAt(0x0c)
+	switch {
+	case size == 0 || size == 0xffff:
+		return 0
+	case size == 0x7fff:
+		// Modules of 32 GB or more use the extended size
+		return uint64(s.dwordAt(0x1c) & 0x7fffffff)
+	case size&0x8000 != 0:
+		// Size in KB
+		return uint64(size&0x7fff) / 1024
+	default:
+		return uint64(size)
+	}
+}
+
+// memoryDeviceSpeed prefers the configured speed, the speed of the module can be higher
+func memoryDeviceSpeed(s *SMBIOSStructure) uint32 {
+	for _, offsets := range [][2]int{{0x20, 0x58}, {0x15, 0x54}} {
+		speed := uint32(s.wordAt(offsets[0]))
+		if speed == 0xffff {
+			speed = s.dwordAt(offsets[1])
+		}
+		if speed != 0 {
+			return speed
+		}
+	}
+	return 0
+}
+
+// convertMBToUnits only uses GB for whole gigabytes e.g 1536 MB is not 1 GB
+func convertMBToUnits(size uint64) string {
+	if size >= 1024 && size%1024 == 0 {
+		return fmt.Sprintf("%d GB", size/1024)
+	}
+	return fmt.Sprintf("%d MB", size)
+}
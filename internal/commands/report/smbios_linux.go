@@ -0,0 +1,96 @@
+//go:build linux
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	scnorion_nats "github.com/scncore/nats"
+)
+
+// The kernel exposes the raw table, root privileges are required to read it
+const SMBIOS_TABLE_PATH = "/sys/firmware/dmi/tables/DMI"
+
+func readSMBIOS() ([]SMBIOSStructure, error) {
+	table, err := os.ReadFile(SMBIOS_TABLE_PATH)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSMBIOS(table)
+}
+
+// getSMBIOSComputerInfo fills the computer information from the BIOS, system and chassis structures
+func (r *Report) getSMBIOSComputerInfo() error {
+	structures, err := readSMBIOS()
+	if err != nil {
+		return err
+	}
+
+	details := ComputerDetails{}
+	for _, s := range structures {
+		switch s.Type {
+		case SMBIOS_BIOS:
+			details.BIOSVendor = s.stringAt(0x04)
+			details.BIOSVersion = s.stringAt(0x05)
+			details.BIOSDate = s.stringAt(0x08)
+		case SMBIOS_SYSTEM:
+			r.Computer.Manufacturer = s.stringAt(0x04)
+			r.Computer.Model = s.stringAt(0x05)
+			r.Computer.Serial = s.stringAt(0x07)
+			details.SKU = s.stringAt(0x19)
+			details.Family = s.stringAt(0x1a)
+		case SMBIOS_CHASSIS:
+			// Only the first chassis is the computer, others are docks or expansions
+			if details.ChassisType == "" {
+				details.ChassisType = chassisTypeName(s.byteAt(0x05))
+				details.AssetTag = s.stringAt(0x08)
+				if r.Computer.Serial == "" {
+					r.Computer.Serial = s.stringAt(0x07)
+				}
+			}
+		}
+	}
+	r.ComputerDetails = &details
+
+	return nil
+}
+
+// getSMBIOSMemorySlots reports every memory device including the empty slots
+func (r *Report) getSMBIOSMemorySlots() error {
+	structures, err := readSMBIOS()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range structures {
+		if s.Type != SMBIOS_MEMORY_DEVICE {
+			continue
+		}
+
+		slot := scnorion_nats.MemorySlot{Slot: s.stringAt(0x10)}
+		if bank := s.stringAt(0x11); bank != "" && slot.Slot == "" {
+			slot.Slot = bank
+		}
+		if slot.Slot == "" {
+			slot.Slot = fmt.Sprintf("Slot %d", len(r.MemorySlots))
+		}
+
+		size := memoryDeviceSize(&s)
+		if size > 0 {
+			slot.Size = convertMBToUnits(size)
+			slot.MemoryType = memoryTypes[s.byteAt(0x12)]
+			if speed := memoryDeviceSpeed(&s); speed > 0 {
+				slot.Speed = strconv.Itoa(int(speed)) + " MHz"
+			}
+			slot.Manufacturer = s.stringAt(0x17)
+			slot.SerialNumber = s.stringAt(0x18)
+			slot.PartNumber = s.stringAt(0x1a)
+		}
+
+		r.MemorySlots = append(r.MemorySlots, slot)
+	}
+
+	return nil
+}
@@ -6,46 +6,70 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/scncore/nats"
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
 	"github.com/scncore/scnorion-agent/internal/commands/runtime"
+	"gopkg.in/ini.v1"
 )
 
+// Settings of the KDE discover notifier, relative to the user's home
+const DISCOVER_UPDATES_CONFIG = ".config/PlasmaDiscoverUpdates"
+
 func (r *Report) getSystemUpdateInfo() error {
-	switch r.OS {
-	case "ubuntu", "debian", "linuxmint", "neon":
-		if err := r.getAptInformation(); err != nil {
+	pm, err := packagemanager.System()
+	if err != nil {
+		r.SystemUpdate.Status = nats.UNKNOWN
+		return nil
+	}
+
+	switch pm.Name() {
+	case "apt":
+		if err := r.getAptInformation(pm); err != nil {
 			log.Printf("[ERROR]: could not get pending security updates, reason: %v", err)
 		} else {
 			log.Println("[INFO]: get pending security updates info has been retrieved")
 		}
-	case "fedora", "almalinux", "redhat", "rocky":
-		if err := r.getDnfInformation(); err != nil {
+	case "dnf":
+		if err := r.getDnfInformation(pm); err != nil {
 			log.Printf("[ERROR]: could not get pending security updates, reason: %v", err)
 		} else {
 			log.Println("[INFO]: get pending security updates info has been retrieved")
 		}
+	case "zypper":
+		r.getZypperInformation(pm)
+		log.Println("[INFO]: get pending security updates info has been retrieved")
+	case "pacman":
+		r.getPacmanInformation(pm)
+		log.Println("[INFO]: get pending security updates info has been retrieved")
 	default:
-		r.SystemUpdate.Status = nats.UNKNOWN
+		// We don't know how updates are configured but we can tell if security updates are pending
+		r.SystemUpdate.Status = checkPackageKitUpdatesStatus()
+		if r.SystemUpdate.Status == nats.NOT_CONFIGURED {
+			r.SystemUpdate.Status = nats.UNKNOWN
+		}
+		r.SystemUpdate.PendingUpdates = r.getPendingUpdates(pm)
 	}
 
 	return nil
 }
 
-func (r *Report) getAptInformation() error {
+func (r *Report) getAptInformation(pm packagemanager.PackageManager) error {
 
 	// Check if we've security updates that can be upgraded
-	r.SystemUpdate.PendingUpdates = checkAptSecurityUpdatesAvailable()
+	r.SystemUpdate.PendingUpdates = r.getPendingUpdates(pm)
 
 	// Check if unattended is running
 	r.SystemUpdate.Status = checkUpdatesStatus()
 
-	// Check if gnome software updares is set
-	if r.SystemUpdate.Status == nats.NOT_CONFIGURED && IsGnomeDesktop() && IsGnomeSoftwareUpdatesEnabled() {
-		r.SystemUpdate.Status = nats.NOTIFY_SCHEDULED_INSTALLATION
+	// Check if gnome software or another PackageKit frontend takes care of updates
+	if r.SystemUpdate.Status == nats.NOT_CONFIGURED {
+		r.SystemUpdate.Status = checkPackageKitUpdatesStatus()
 	}
 
 	// Check last time packages were installed
@@ -54,62 +78,74 @@ func (r *Report) getAptInformation() error {
 	return nil
 }
 
-func (r *Report) getDnfInformation() error {
+func (r *Report) getDnfInformation(pm packagemanager.PackageManager) error {
 
 	// Check if we've security updates that can be upgraded
-	r.SystemUpdate.PendingUpdates = checkDnfSecurityUpdatesAvailable()
+	r.SystemUpdate.PendingUpdates = r.getPendingUpdates(pm)
 
-	// Check if gnome software updares is set
-	if r.SystemUpdate.Status == nats.NOT_CONFIGURED && IsGnomeDesktop() && IsGnomeSoftwareUpdatesEnabled() {
-		r.SystemUpdate.Status = nats.NOTIFY_SCHEDULED_INSTALLATION
-	}
-
-	// Check if unattended is running
+	// Check if dnf-automatic is running
 	r.SystemUpdate.Status = checkDnfUpdatesStatus()
 
+	// Check if gnome software or another PackageKit frontend takes care of updates
+	if r.SystemUpdate.Status == nats.NOT_CONFIGURED {
+		r.SystemUpdate.Status = checkPackageKitUpdatesStatus()
+	}
+
 	// Check last time packages were installed
 	r.SystemUpdate.LastInstall = checkDnfLastTimePackagesInstalled()
 
 	return nil
 }
 
-func checkAptSecurityUpdatesAvailable() bool {
-	if err := exec.Command("apt", "update").Run(); err != nil {
-		log.Printf("[ERROR]: could not run apt update, reason: %v", err)
-		return false
-	}
+func (r *Report) getZypperInformation(pm packagemanager.PackageManager) {
 
-	secUpdatesAvailable := `apt list --upgradable 2>/dev/null | grep "\-security" | wc -l`
-	out, err := exec.Command("bash", "-c", secUpdatesAvailable).Output()
-	if err != nil {
-		log.Printf("[ERROR]: could not check if updates are available, reason: %v", err)
-		return false
-	}
+	// Check if we've security updates or patches that can be installed
+	r.SystemUpdate.PendingUpdates = r.getPendingUpdates(pm)
 
-	nUpdates, err := strconv.Atoi(strings.TrimSpace(string(out)))
-	if err != nil {
-		log.Printf("[ERROR]: could not get the number of updates available, reason: %v", err)
-		return false
+	// Check if zypper-auto, the online update cron job or MicroOS updates are enabled
+	r.SystemUpdate.Status = checkZypperUpdatesStatus()
+
+	// Check if gnome software or another PackageKit frontend takes care of updates
+	if r.SystemUpdate.Status == nats.NOT_CONFIGURED {
+		r.SystemUpdate.Status = checkPackageKitUpdatesStatus()
 	}
 
-	return nUpdates > 0
+	// Check last time packages were installed
+	r.SystemUpdate.LastInstall = checkZypperLastTimePackagesInstalled()
+}
+
+func (r *Report) getPacmanInformation(pm packagemanager.PackageManager) {
+
+	// Check if we've updates that can be upgraded, Arch has no security classification
+	r.SystemUpdate.PendingUpdates = r.getPendingUpdates(pm)
+
+	// Arch discourages unattended upgrades so only a PackageKit frontend can take care of updates
+	r.SystemUpdate.Status = checkPackageKitUpdatesStatus()
+
+	// Check last time packages were installed
+	r.SystemUpdate.LastInstall = checkPacmanLastTimePackagesInstalled()
 }
 
-func checkDnfSecurityUpdatesAvailable() bool {
-	secUpdatesAvailable := `dnf check-update --refresh --security | wc -l`
-	out, err := exec.Command("bash", "-c", secUpdatesAvailable).Output()
+// Only these package managers tell which updates fix security issues
+var securityClassifiedManagers = []string{"apt", "dnf", "zypper"}
+
+// getPendingUpdates fills the pending updates list and tells if there are security updates,
+// or any update if the package manager doesn't classify them
+func (r *Report) getPendingUpdates(pm packagemanager.PackageManager) bool {
+	updates, err := pm.PendingUpdates()
 	if err != nil {
 		log.Printf("[ERROR]: could not check if updates are available, reason: %v", err)
 		return false
 	}
 
-	nUpdates, err := strconv.Atoi(strings.TrimSpace(string(out)))
-	if err != nil {
-		log.Printf("[ERROR]: could not get the number of updates available, reason: %v", err)
-		return false
-	}
+	list := PendingUpdates{Updates: updates}
+	list.countSeverities()
+	r.PendingUpdateList = &list
 
-	return nUpdates > 0
+	if !slices.Contains(securityClassifiedManagers, pm.Name()) {
+		return list.Total > 0
+	}
+	return list.Security > 0
 }
 
 func checkUpdatesStatus() string {
@@ -180,15 +216,167 @@ func checkDnfLastTimePackagesInstalled() time.Time {
 	return t
 }
 
+// checkDnfUpdatesStatus uses the dnf-automatic timer that is enabled, the default timer
+// follows the settings in automatic.conf
 func checkDnfUpdatesStatus() string {
-	_, err := os.Stat("/etc/dnf/automatic.conf")
-	if err == nil {
+	switch {
+	case isSystemdUnitEnabled("dnf-automatic-install.timer"):
 		return nats.NOTIFY_SCHEDULED_INSTALLATION
-	} else {
+	case isSystemdUnitEnabled("dnf-automatic-download.timer"):
+		return nats.NOTIFY_BEFORE_INSTALLATION
+	case isSystemdUnitEnabled("dnf-automatic-notifyonly.timer"):
+		return nats.NOTIFY_BEFORE_DOWNLOAD
+	case isSystemdUnitEnabled("dnf-automatic.timer"), isSystemdUnitEnabled("dnf5-automatic.timer"):
+		settings := readDnfAutomaticSettings()
+		switch {
+		case settings["apply_updates"]:
+			return nats.NOTIFY_SCHEDULED_INSTALLATION
+		case settings["download_updates"]:
+			return nats.NOTIFY_BEFORE_INSTALLATION
+		default:
+			return nats.NOTIFY_BEFORE_DOWNLOAD
+		}
+	default:
 		return nats.NOT_CONFIGURED
 	}
 }
 
+// readDnfAutomaticSettings reads the boolean settings of the [commands] section, dnf5 uses its own
+// file that overrides the default one
+func readDnfAutomaticSettings() map[string]bool {
+	settings := map[string]bool{}
+
+	for _, path := range []string{"/etc/dnf/automatic.conf", "/etc/dnf/dnf5-plugins/automatic.conf"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		section := ""
+		for line := range strings.SplitSeq(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+				section = strings.Trim(line, "[]")
+				continue
+			}
+			key, value, found := strings.Cut(line, "=")
+			if !found || section != "commands" {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "yes", "true", "1", "on":
+				settings[strings.TrimSpace(key)] = true
+			case "no", "false", "0", "off":
+				settings[strings.TrimSpace(key)] = false
+			}
+		}
+	}
+
+	return settings
+}
+
+// checkZypperUpdatesStatus looks for the known ways of installing updates automatically on openSUSE
+func checkZypperUpdatesStatus() string {
+	// zypper-auto and MicroOS/transactional systems install updates on their own
+	for _, unit := range []string{"zypper-auto.timer", "zypper-auto.service", "os-update.timer", "transactional-update.timer"} {
+		if isSystemdUnitEnabled(unit) {
+			return nats.NOTIFY_SCHEDULED_INSTALLATION
+		}
+	}
+
+	// YaST automatic online update adds a cron job
+	for _, period := range []string{"daily", "weekly", "monthly"} {
+		if _, err := os.Stat("/etc/cron." + period + "/opensuse.org-online_update"); err == nil {
+			return nats.NOTIFY_SCHEDULED_INSTALLATION
+		}
+	}
+
+	return nats.NOT_CONFIGURED
+}
+
+// checkPackageKitUpdatesStatus tells if a desktop frontend (gnome software, discover...) has been
+// set to install updates on its own. PackageKit itself is a static unit activated by D-Bus on
+// every desktop, that doesn't mean that updates are taken care of
+func checkPackageKitUpdatesStatus() string {
+	// Check if gnome software updates are set
+	if IsGnomeDesktop() && IsGnomeSoftwareUpdatesEnabled() {
+		return nats.NOTIFY_SCHEDULED_INSTALLATION
+	}
+
+	// Check if discover unattended updates are set
+	if IsDiscoverUnattendedUpdatesEnabled() {
+		return nats.NOTIFY_SCHEDULED_INSTALLATION
+	}
+
+	return nats.NOT_CONFIGURED
+}
+
+// isSystemdUnitEnabled returns true only for units that are started on their own, static
+// units are started by another unit or by D-Bus
+func isSystemdUnitEnabled(unit string) bool {
+	// is-enabled exits with an error for some of the states so we only use the output
+	out, _ := exec.Command("systemctl", "is-enabled", unit).Output()
+	state := strings.TrimSpace(string(out))
+	return state == "enabled" || state == "enabled-runtime"
+}
+
+// checkZypperLastTimePackagesInstalled reads the zypp history, each line is
+// date|action|name|version|arch|user|repository|checksum|
+func checkZypperLastTimePackagesInstalled() time.Time {
+	data, err := os.ReadFile("/var/log/zypp/history")
+	if err != nil {
+		log.Printf("[ERROR]: could not read zypp history log, reason: %v", err)
+		return time.Time{}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		fields := strings.Split(lines[i], "|")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") || fields[1] != "install" {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", strings.TrimSpace(fields[0]), time.Local)
+		if err != nil {
+			log.Printf("[ERROR]: could not parse time string %s from zypp history log, reason: %v", fields[0], err)
+			return time.Time{}
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// checkPacmanLastTimePackagesInstalled reads the pacman log
+// e.g [2024-05-01T10:11:12+0200] [ALPM] upgraded openssl (3.3.1-1 -> 3.3.2-1)
+func checkPacmanLastTimePackagesInstalled() time.Time {
+	data, err := os.ReadFile("/var/log/pacman.log")
+	if err != nil {
+		log.Printf("[ERROR]: could not read pacman log, reason: %v", err)
+		return time.Time{}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		date, message, found := strings.Cut(strings.TrimPrefix(lines[i], "["), "] ")
+		if !found || (!strings.HasPrefix(message, "[ALPM] installed ") && !strings.HasPrefix(message, "[ALPM] upgraded ")) {
+			continue
+		}
+
+		// Old pacman versions used local time without seconds
+		for _, layout := range []string{"2006-01-02T15:04:05-0700", "2006-01-02 15:04"} {
+			if t, err := time.ParseInLocation(layout, date, time.Local); err == nil {
+				return t
+			}
+		}
+
+		log.Printf("[ERROR]: could not parse time string %s from pacman log", date)
+		return time.Time{}
+	}
+
+	return time.Time{}
+}
+
 func IsGnomeDesktop() bool {
 	session, err := runtime.GetUserEnv("DESKTOP_SESSION")
 	return err == nil && session == "gnome"
@@ -217,3 +405,22 @@ func IsGnomeSoftwareUpdatesEnabled() bool {
 	}
 	return false
 }
+
+// IsDiscoverUnattendedUpdatesEnabled reads the discover notifier settings of the logged in user
+func IsDiscoverUnattendedUpdatesEnabled() bool {
+	username, err := runtime.GetLoggedInUser()
+	if err != nil {
+		return false
+	}
+
+	home, _, _, err := runtime.GetUserInfo(username)
+	if err != nil {
+		return false
+	}
+
+	cfg, err := ini.Load(filepath.Join(home, DISCOVER_UPDATES_CONFIG))
+	if err != nil {
+		return false
+	}
+	return cfg.Section("Global").Key("UseUnattendedUpdates").MustBool(false)
+}
@@ -2,8 +2,43 @@ package report
 
 import (
 	"fmt"
+
+	"github.com/scncore/scnorion-agent/internal/commands/packagemanager"
 )
 
+type PendingUpdates struct {
+	Updates   []packagemanager.PendingUpdate `json:"updates"`
+	Total     int                            `json:"total"`
+	Security  int                            `json:"security"`
+	Critical  int                            `json:"critical"`
+	Important int                            `json:"important"`
+	Moderate  int                            `json:"moderate"`
+	Low       int                            `json:"low"`
+	Unknown   int                            `json:"unknown"`
+}
+
+func (p *PendingUpdates) countSeverities() {
+	p.Total = len(p.Updates)
+	for _, u := range p.Updates {
+		if !u.Security {
+			continue
+		}
+		p.Security++
+		switch u.Severity {
+		case packagemanager.SEVERITY_CRITICAL:
+			p.Critical++
+		case packagemanager.SEVERITY_IMPORTANT:
+			p.Important++
+		case packagemanager.SEVERITY_MODERATE:
+			p.Moderate++
+		case packagemanager.SEVERITY_LOW:
+			p.Low++
+		default:
+			p.Unknown++
+		}
+	}
+}
+
 func (r *Report) logSystemUpdate() {
 	fmt.Printf("\n** 🔄 Updates *******************************************************************************************************\n")
 	fmt.Printf("%-40s |  %s \n", "Automatic Updates status", r.SystemUpdate.Status)
@@ -18,4 +53,9 @@ func (r *Report) logSystemUpdate() {
 		fmt.Printf("%-40s |  %v \n", "Last updates search date", r.SystemUpdate.LastSearch)
 	}
 	fmt.Printf("%-40s |  %t \n", "Pending updates", r.SystemUpdate.PendingUpdates)
+	if r.PendingUpdateList != nil {
+		fmt.Printf("%-40s |  %d \n", "Pending updates count", r.PendingUpdateList.Total)
+		fmt.Printf("%-40s |  %d (critical %d, important %d, moderate %d, low %d, unknown %d) \n", "Pending security updates",
+			r.PendingUpdateList.Security, r.PendingUpdateList.Critical, r.PendingUpdateList.Important, r.PendingUpdateList.Moderate, r.PendingUpdateList.Low, r.PendingUpdateList.Unknown)
+	}
 }
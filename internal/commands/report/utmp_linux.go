@@ -0,0 +1,110 @@
+//go:build linux
+
+package report
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// glibc utmp record used by wtmp and btmp, it has the same size on 32 and 64 bits
+const UTMP_RECORD_SIZE = 384
+
+// ut_type values
+const (
+	UTMP_BOOT_TIME     = 2
+	UTMP_LOGIN_PROCESS = 6
+	UTMP_USER_PROCESS  = 7
+	UTMP_DEAD_PROCESS  = 8
+)
+
+const (
+	WTMP_PATH = "/var/log/wtmp"
+	BTMP_PATH = "/var/log/btmp"
+)
+
+type utmpRecord struct {
+	Type    int16
+	PID     int32
+	Line    string
+	ID      string
+	User    string
+	Host    string
+	Session int32
+	Time    time.Time
+	Address string
+}
+
+func parseUtmpRecord(b []byte) utmpRecord {
+	r := utmpRecord{
+		Type:    int16(binary.LittleEndian.Uint16(b[0:2])),
+		PID:     int32(binary.LittleEndian.Uint32(b[4:8])),
+		Line:    cString(b[8:40]),
+		ID:      cString(b[40:44]),
+		User:    cString(b[44:76]),
+		Host:    cString(b[76:332]),
+		Session: int32(binary.LittleEndian.Uint32(b[336:340])),
+	}
+
+	sec := int64(int32(binary.LittleEndian.Uint32(b[340:344])))
+	usec := int64(int32(binary.LittleEndian.Uint32(b[344:348])))
+	r.Time = time.Unix(sec, usec*1000)
+
+	// IPv4 addresses only use the first 4 bytes
+	addr := b[348:364]
+	if !bytes.Equal(addr[4:], make([]byte, 12)) {
+		r.Address = net.IP(addr).String()
+	} else if !bytes.Equal(addr[:4], make([]byte, 4)) {
+		r.Address = net.IP(addr[:4]).String()
+	}
+
+	return r
+}
+
+// readUtmpFile reads the records starting at offset and returns the offset of the end of
+// the last complete record so the next read can continue from there
+func readUtmpFile(path string, offset int64) ([]utmpRecord, int64, error) {
+	records := []utmpRecord{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, offset, err
+	}
+
+	// The file has been rotated
+	if offset > info.Size() || offset%UTMP_RECORD_SIZE != 0 {
+		offset = 0
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	buf := make([]byte, UTMP_RECORD_SIZE)
+	for {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			break
+		}
+		records = append(records, parseUtmpRecord(buf))
+		offset += UTMP_RECORD_SIZE
+	}
+
+	return records, offset, nil
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
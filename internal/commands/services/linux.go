@@ -0,0 +1,178 @@
+//go:build linux
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Properties read with systemctl show, the states from list-units are completed with them
+var showProperties = []string{"Id", "Description", "LoadState", "ActiveState", "SubState", "UnitFileState", "MainPID", "Result", "ExecMainStatus"}
+
+type listUnitsEntry struct {
+	Unit        string `json:"unit"`
+	Load        string `json:"load"`
+	Active      string `json:"active"`
+	Sub         string `json:"sub"`
+	Description string `json:"description"`
+}
+
+// List returns the services known by systemd, loaded or not
+func List() ([]Unit, error) {
+	names, err := listUnitNames("--all", "--type=service")
+	if err != nil {
+		return nil, err
+	}
+	return Show(names)
+}
+
+// ListFailed returns the units of any type in failed state
+func ListFailed() ([]Unit, error) {
+	names, err := listUnitNames("--state=failed")
+	if err != nil {
+		return nil, err
+	}
+	return Show(names)
+}
+
+// listUnitNames uses the JSON output if systemd supports it (v246+) or the plain output
+func listUnitNames(args ...string) ([]string, error) {
+	names := []string{}
+
+	out, err := exec.Command("systemctl", append([]string{"list-units", "--no-pager", "--output=json"}, args...)...).Output()
+	if err == nil {
+		entries := []listUnitsEntry{}
+		if err := json.Unmarshal(out, &entries); err == nil {
+			for _, e := range entries {
+				names = append(names, e.Unit)
+			}
+			return names, nil
+		}
+	}
+
+	out, err = exec.Command("systemctl", append([]string{"list-units", "--no-pager", "--plain", "--no-legend"}, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list systemd units, reason: %v", err)
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names, nil
+}
+
+// Show reads the properties of the units with only one systemctl call,
+// systemctl prints a block of properties for each unit separated by an empty line
+func Show(names []string) ([]Unit, error) {
+	units := []Unit{}
+	if len(names) == 0 {
+		return units, nil
+	}
+
+	args := append([]string{"show", "--no-pager", "--property=" + strings.Join(showProperties, ","), "--"}, names...)
+	out, err := exec.Command("systemctl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the properties of systemd units, reason: %v", err)
+	}
+
+	for block := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n\n") {
+		u := Unit{}
+		for line := range strings.SplitSeq(block, "\n") {
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				continue
+			}
+			switch key {
+			case "Id":
+				u.Name = value
+			case "Description":
+				u.Description = value
+			case "LoadState":
+				u.LoadState = value
+			case "ActiveState":
+				u.ActiveState = value
+			case "SubState":
+				u.SubState = value
+			case "UnitFileState":
+				u.UnitFileState = value
+			case "MainPID":
+				u.MainPID, _ = strconv.Atoi(value)
+			case "Result":
+				u.Result = value
+			case "ExecMainStatus":
+				u.ExecMainStatus, _ = strconv.Atoi(value)
+			}
+		}
+		if u.Name != "" {
+			units = append(units, u)
+		}
+	}
+
+	return units, nil
+}
+
+// Control runs the action on the unit and returns its state afterwards
+func Control(req ServiceRequest) (*Unit, error) {
+	if !unitNameRegexp.MatchString(req.Unit) {
+		return nil, fmt.Errorf("unit name %s is not valid", req.Unit)
+	}
+
+	// systemctl accepts names without suffix as services
+	unit := req.Unit
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+
+	switch req.Action {
+	case ACTION_START, ACTION_RESTART, ACTION_ENABLE, ACTION_UNMASK:
+	case ACTION_STOP, ACTION_DISABLE, ACTION_MASK:
+		for _, name := range unitNames(unit) {
+			if slices.Contains(protectedUnits, name) {
+				return nil, fmt.Errorf("unit %s is protected and it can't be stopped, disabled or masked remotely", name)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("action %s is not valid", req.Action)
+	}
+
+	if out, err := exec.Command("systemctl", "--no-pager", req.Action, "--", unit).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("systemctl %s %s failed, reason: %v, output: %s", req.Action, unit, err, strings.TrimSpace(string(out)))
+	}
+
+	units, err := Show([]string{unit})
+	if err != nil {
+		return nil, err
+	}
+	if len(units) == 0 {
+		return nil, fmt.Errorf("unit %s was not found", unit)
+	}
+	return &units[0], nil
+}
+
+// unitNames returns the name systemd resolves the unit to and all its aliases,
+// the requested name is returned too in case the unit doesn't exist
+func unitNames(unit string) []string {
+	names := []string{unit}
+
+	out, err := exec.Command("systemctl", "show", "--no-pager", "--property=Id,Names", "--", unit).Output()
+	if err != nil {
+		return names
+	}
+
+	for line := range strings.SplitSeq(string(out), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found || (key != "Id" && key != "Names") {
+			continue
+		}
+		names = append(names, strings.Fields(value)...)
+	}
+	return names
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"regexp"
+)
+
+const (
+	ACTION_START   = "start"
+	ACTION_STOP    = "stop"
+	ACTION_RESTART = "restart"
+	ACTION_ENABLE  = "enable"
+	ACTION_DISABLE = "disable"
+	ACTION_MASK    = "mask"
+	ACTION_UNMASK  = "unmask"
+)
+
+// Units that can't be stopped, disabled or masked remotely as we'd lose access to the machine,
+// aliases are resolved before checking them e.g dbus.service is dbus-broker.service on Fedora
+var protectedUnits = []string{
+	"scnorion-agent.service", "sshd.service", "ssh.service", "ssh.socket", "sshd.socket",
+	"dbus.service", "dbus.socket", "dbus-broker.service", "systemd-journald.service",
+}
+
+// Unit names can have letters, digits, :-_.\ and @ for template instances
+var unitNameRegexp = regexp.MustCompile(`^[A-Za-z0-9:_.@\\-]+$`)
+
+type Unit struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	LoadState     string `json:"load_state"`
+	ActiveState   string `json:"active_state"`
+	SubState      string `json:"sub_state"`
+	UnitFileState string `json:"unit_file_state,omitempty"`
+	MainPID       int    `json:"main_pid,omitempty"`
+	// Result tells why a failed unit failed e.g exit-code, signal, timeout
+	Result         string `json:"result,omitempty"`
+	ExecMainStatus int    `json:"exec_main_status,omitempty"`
+}
+
+type ServiceRequest struct {
+	Unit   string `json:"unit"`
+	Action string `json:"action"`
+}
+
+type ServiceResult struct {
+	Unit  *Unit  `json:"unit,omitempty"`
+	Error string `json:"error,omitempty"`
+}
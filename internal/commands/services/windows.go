@@ -0,0 +1,17 @@
+//go:build windows
+
+package services
+
+import "errors"
+
+func List() ([]Unit, error) {
+	return nil, errors.New("services inventory is not supported on this operating system")
+}
+
+func ListFailed() ([]Unit, error) {
+	return nil, errors.New("services inventory is not supported on this operating system")
+}
+
+func Control(req ServiceRequest) (*Unit, error) {
+	return nil, errors.New("service control is not supported on this operating system")
+}
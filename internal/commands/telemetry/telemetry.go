@@ -0,0 +1,268 @@
+package telemetry
+
+import (
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+const DEFAULT_SAMPLE_INTERVAL_SECONDS = 60
+
+// Samples are dropped from the oldest if the report can't be sent for a long time,
+// a day of samples at the default interval
+const MAX_SAMPLES = 1440
+
+// Aggregate summarizes the samples of a metric, rates are in bytes per second
+// and usages are percentages
+type Aggregate struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P95 float64 `json:"p95"`
+}
+
+// Telemetry is attached to the report and covers the samples taken since the last report was sent
+type Telemetry struct {
+	PeriodStart     time.Time            `json:"period_start"`
+	PeriodEnd       time.Time            `json:"period_end"`
+	IntervalSeconds int                  `json:"interval_seconds"`
+	Samples         int                  `json:"samples"`
+	CPU             Aggregate            `json:"cpu"`
+	Memory          Aggregate            `json:"memory"`
+	Swap            Aggregate            `json:"swap"`
+	DiskRead        Aggregate            `json:"disk_read"`
+	DiskWrite       Aggregate            `json:"disk_write"`
+	NetworkReceived Aggregate            `json:"network_received"`
+	NetworkSent     Aggregate            `json:"network_sent"`
+	Mounts          map[string]Aggregate `json:"mounts,omitempty"`
+}
+
+type sample struct {
+	time            time.Time
+	cpu             float64
+	memory          float64
+	swap            float64
+	diskRead        float64
+	diskWrite       float64
+	networkReceived float64
+	networkSent     float64
+	mounts          map[string]float64
+}
+
+// counters are cumulative so rates are computed from the previous sample
+type counters struct {
+	time            time.Time
+	diskRead        uint64
+	diskWrite       uint64
+	networkReceived uint64
+	networkSent     uint64
+}
+
+type Sampler struct {
+	Interval time.Duration
+	mu       sync.Mutex
+	samples  []sample
+	previous *counters
+}
+
+func New(interval time.Duration) *Sampler {
+	if interval <= 0 {
+		interval = DEFAULT_SAMPLE_INTERVAL_SECONDS * time.Second
+	}
+	return &Sampler{Interval: interval, samples: []sample{}}
+}
+
+// Sample takes a sample of every metric, metrics that can't be read are left as 0
+func (s *Sampler) Sample() {
+	now := time.Now()
+	current := sample{time: now, mounts: map[string]float64{}}
+
+	// CPU usage since the previous call, the first call has no reference and returns 0
+	if percents, err := cpu.Percent(0, false); err == nil && len(percents) > 0 {
+		current.cpu = percents[0]
+	} else if err != nil {
+		log.Printf("[ERROR]: could not get CPU usage, reason: %v", err)
+	}
+
+	if v, err := mem.VirtualMemory(); err == nil {
+		current.memory = v.UsedPercent
+	}
+
+	if sw, err := mem.SwapMemory(); err == nil {
+		current.swap = sw.UsedPercent
+	}
+
+	c := counters{time: now}
+	if io, err := disk.IOCounters(); err == nil {
+		for name, d := range io {
+			if !isWholeDisk(name, io) {
+				continue
+			}
+			c.diskRead += d.ReadBytes
+			c.diskWrite += d.WriteBytes
+		}
+	}
+	if io, err := net.IOCounters(false); err == nil && len(io) > 0 {
+		c.networkReceived = io[0].BytesRecv
+		c.networkSent = io[0].BytesSent
+	}
+
+	if partitions, err := disk.Partitions(false); err == nil {
+		for _, p := range partitions {
+			if usage, err := disk.Usage(p.Mountpoint); err == nil && usage.Total > 0 {
+				current.mounts[p.Mountpoint] = usage.UsedPercent
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.previous
+	s.previous = &c
+
+	// Rates need two samples, counters can also go back if a device is removed
+	if previous == nil {
+		return
+	}
+	seconds := now.Sub(previous.time).Seconds()
+	if seconds <= 0 {
+		return
+	}
+	current.diskRead = rate(previous.diskRead, c.diskRead, seconds)
+	current.diskWrite = rate(previous.diskWrite, c.diskWrite, seconds)
+	current.networkReceived = rate(previous.networkReceived, c.networkReceived, seconds)
+	current.networkSent = rate(previous.networkSent, c.networkSent, seconds)
+
+	s.samples = append(s.samples, current)
+	if len(s.samples) > MAX_SAMPLES {
+		s.samples = s.samples[len(s.samples)-MAX_SAMPLES:]
+	}
+}
+
+// Aggregate returns the aggregates of the samples taken so far, they're kept until Reset is called
+// so they're not lost if the report can't be sent
+func (s *Sampler) Aggregate() *Telemetry {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.samples) == 0 {
+		return nil
+	}
+
+	t := Telemetry{
+		PeriodStart:     s.samples[0].time,
+		PeriodEnd:       s.samples[len(s.samples)-1].time,
+		IntervalSeconds: int(s.Interval.Seconds()),
+		Samples:         len(s.samples),
+		CPU:             aggregate(s.samples, func(x sample) float64 { return x.cpu }),
+		Memory:          aggregate(s.samples, func(x sample) float64 { return x.memory }),
+		Swap:            aggregate(s.samples, func(x sample) float64 { return x.swap }),
+		DiskRead:        aggregate(s.samples, func(x sample) float64 { return x.diskRead }),
+		DiskWrite:       aggregate(s.samples, func(x sample) float64 { return x.diskWrite }),
+		NetworkReceived: aggregate(s.samples, func(x sample) float64 { return x.networkReceived }),
+		NetworkSent:     aggregate(s.samples, func(x sample) float64 { return x.networkSent }),
+		Mounts:          map[string]Aggregate{},
+	}
+
+	// Mounts can come and go during the period so only their samples are used
+	mountpoints := map[string][]float64{}
+	for _, x := range s.samples {
+		for m, usage := range x.mounts {
+			mountpoints[m] = append(mountpoints[m], usage)
+		}
+	}
+	for m, values := range mountpoints {
+		t.Mounts[m] = aggregateValues(values)
+	}
+
+	return &t
+}
+
+// Reset removes the samples that have already been reported
+func (s *Sampler) Reset(until time.Time) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := 0
+	for i < len(s.samples) && !s.samples[i].time.After(until) {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// isWholeDisk skips partitions and virtual devices, their I/O is already counted in the disk
+// e.g sda1 and dm-0 on top of sda
+func isWholeDisk(name string, devices map[string]disk.IOCountersStat) bool {
+	for _, prefix := range []string{"loop", "ram", "zram", "dm-", "md", "sr"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+
+	for other := range devices {
+		if other != name && strings.HasPrefix(name, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func rate(previous, current uint64, seconds float64) float64 {
+	if current < previous {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
+
+func aggregate(samples []sample, value func(sample) float64) Aggregate {
+	values := make([]float64, 0, len(samples))
+	for _, x := range samples {
+		values = append(values, value(x))
+	}
+	return aggregateValues(values)
+}
+
+func aggregateValues(values []float64) Aggregate {
+	if len(values) == 0 {
+		return Aggregate{}
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+
+	// Nearest-rank percentile
+	p95 := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+
+	return Aggregate{
+		Min: round(sorted[0]),
+		Avg: round(sum / float64(len(sorted))),
+		Max: round(sorted[len(sorted)-1]),
+		P95: round(sorted[max(p95, 0)]),
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,149 @@
+package wol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+)
+
+const DEFAULT_WOL_PORT = 9
+
+type WakeOnLANRequest struct {
+	MACAddresses []string `json:"mac_addresses"`
+	Port         int      `json:"port,omitempty"`
+}
+
+type WakeOnLANResult struct {
+	Sent  []string `json:"sent"`
+	Error string   `json:"error,omitempty"`
+}
+
+// WakeUp sends a magic packet for every MAC address through all the active IPv4
+// interfaces so the sleeping machines in our broadcast domains receive them
+func WakeUp(req WakeOnLANRequest) (*WakeOnLANResult, error) {
+	result := WakeOnLANResult{Sent: []string{}}
+
+	port := req.Port
+	if port <= 0 || port > 65535 {
+		port = DEFAULT_WOL_PORT
+	}
+
+	targets, err := getBroadcastTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("no active network interfaces with an IPv4 address were found")
+	}
+
+	errMessages := []string{}
+	for _, mac := range req.MACAddresses {
+		packet, err := NewMagicPacket(mac)
+		if err != nil {
+			errMessages = append(errMessages, err.Error())
+			continue
+		}
+
+		sent := false
+		for _, t := range targets {
+			if err := sendMagicPacket(packet, t.local, t.broadcast, port); err != nil {
+				log.Printf("[ERROR]: could not send WoL packet for %s through %s, reason: %v", mac, t.name, err)
+				continue
+			}
+			sent = true
+		}
+
+		if sent {
+			log.Printf("[INFO]: WoL packet has been sent to %s", mac)
+			result.Sent = append(result.Sent, mac)
+		} else {
+			errMessages = append(errMessages, fmt.Sprintf("could not send WoL packet to %s", mac))
+		}
+	}
+
+	result.Error = strings.Join(errMessages, ", ")
+	return &result, nil
+}
+
+// NewMagicPacket returns 6 bytes of 0xFF followed by 16 repetitions of the MAC address
+func NewMagicPacket(mac string) ([]byte, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid MAC address", mac)
+	}
+
+	if len(hw) != 6 {
+		return nil, fmt.Errorf("%s is not an Ethernet MAC address", mac)
+	}
+
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	packet = append(packet, bytes.Repeat(hw, 16)...)
+	return packet, nil
+}
+
+type broadcastTarget struct {
+	name      string
+	local     net.IP
+	broadcast net.IP
+}
+
+func getBroadcastTargets() ([]broadcastTarget, error) {
+	targets := []broadcastTarget{}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range ifaces {
+		if i.Flags&net.FlagUp == 0 || i.Flags&net.FlagLoopback != 0 || i.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+
+		addresses, err := i.Addrs()
+		if err != nil {
+			log.Printf("[ERROR]: could not get IP addresses assigned to interface %s, %v\n", i.Name, err)
+			continue
+		}
+
+		for _, a := range addresses {
+			ipNet, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			ipv4 := ipNet.IP.To4()
+			if ipv4 == nil || len(ipNet.Mask) != net.IPv4len {
+				continue
+			}
+
+			broadcast := make(net.IP, net.IPv4len)
+			for b := range broadcast {
+				broadcast[b] = ipv4[b] | ^ipNet.Mask[b]
+			}
+
+			targets = append(targets, broadcastTarget{name: i.Name, local: ipv4, broadcast: broadcast})
+		}
+	}
+
+	return targets, nil
+}
+
+func sendMagicPacket(packet []byte, local, broadcast net.IP, port int) error {
+	// Binding to the interface address makes the OS use that interface
+	conn, err := net.DialUDP("udp4", &net.UDPAddr{IP: local}, &net.UDPAddr{IP: broadcast, Port: port})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(packet); err != nil {
+		return err
+	}
+
+	return nil
+}